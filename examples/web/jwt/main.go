@@ -19,8 +19,10 @@ import (
 	"github.com/hidevopsio/hiboot/pkg/app/web"
 	// starter/actuator is response for health check, etc.
 	_ "github.com/hidevopsio/hiboot/pkg/starter/actuator"
+	_ "github.com/hidevopsio/hiboot/pkg/starter/cors"
 	_ "github.com/hidevopsio/hiboot/pkg/starter/locale"
 	_ "github.com/hidevopsio/hiboot/pkg/starter/logging"
+	_ "github.com/hidevopsio/hiboot/pkg/starter/ratelimit"
 )
 
 func main() {
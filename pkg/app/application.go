@@ -43,6 +43,11 @@ type ApplicationContext interface {
 	GetInstance(name string) (instance interface{})
 }
 
+// Destroyer is implemented by the instance or configuration that releases its resources on application shutdown
+type Destroyer interface {
+	Destroy()
+}
+
 type Configuration interface{}
 type PreConfiguration interface{}
 type PostConfiguration interface{}
@@ -130,13 +135,41 @@ func (a *BaseApplication) PrintStartupMessages() {
 
 // SetProperty set application property
 func (a *BaseApplication) SetProperty(name string, value interface{}) Application {
+	if a.propertyMap == nil {
+		a.propertyMap = cmap.New()
+	}
 	a.propertyMap.Set(name, value)
 	return a
 }
 
 // GetProperty get application property
 func (a *BaseApplication) GetProperty(name string) (value interface{}, ok bool) {
-	value, ok = a.propertyMap.Get(name)
+	if a.propertyMap != nil {
+		value, ok = a.propertyMap.Get(name)
+	}
+	return
+}
+
+// getStringProperty get application property in string
+func (a *BaseApplication) getStringProperty(name string) (value string) {
+	if prop, ok := a.GetProperty(name); ok {
+		value, _ = prop.(string)
+	}
+	return
+}
+
+// ConfigLocation get config location from application properties
+func (a *BaseApplication) ConfigLocation() (paths []string, name string, file string) {
+	if prop, ok := a.GetProperty(PropertyConfigPath); ok {
+		switch p := prop.(type) {
+		case string:
+			paths = []string{p}
+		case []string:
+			paths = p
+		}
+	}
+	name = a.getStringProperty(PropertyConfigName)
+	file = a.getStringProperty(PropertyConfigFile)
 	return
 }
 
@@ -147,18 +180,21 @@ func (a *BaseApplication) Initialize() error {
 
 	a.WorkDir = io.GetWorkDir()
 
-	a.propertyMap = cmap.New()
+	// keep the properties that are set before initialization
+	if a.propertyMap == nil {
+		a.propertyMap = cmap.New()
+	}
 
 	a.configurations = cmap.New()
 	a.instances = cmap.New()
 
 	instantiateFactory := new(instantiate.InstantiateFactory)
 	instantiateFactory.Initialize(a.instances)
-	a.instances.Set("instantiateFactory", instantiateFactory)
+	instantiateFactory.SetInstance("instantiateFactory", instantiateFactory)
 
 	configurableFactory := new(autoconfigure.ConfigurableFactory)
 	configurableFactory.InstantiateFactory = instantiateFactory
-	a.instances.Set("configurableFactory", configurableFactory)
+	instantiateFactory.SetInstance("configurableFactory", configurableFactory)
 	inject.SetFactory(configurableFactory)
 	a.configurableFactory = configurableFactory
 
@@ -166,6 +202,7 @@ func (a *BaseApplication) Initialize() error {
 
 	err := configurableFactory.Initialize(a.configurations)
 	if err == nil {
+		configurableFactory.SetConfigLocation(a.ConfigLocation())
 		a.systemConfig, err = configurableFactory.BuildSystemConfig()
 	}
 	return nil
@@ -216,6 +253,38 @@ func (a *BaseApplication) Run() error {
 	return nil
 }
 
+// Destroy calls the destroy hooks of all instances and configurations that implement Destroyer, the configurations
+// are destroyed before their instances, and both are destroyed in the reverse order of creation, so that an instance
+// is destroyed before its dependencies
+func (a *BaseApplication) Destroy() {
+	if a.configurableFactory == nil {
+		return
+	}
+	destroyed := make(map[interface{}]bool)
+	destroy(a.configurations, a.configurableFactory.ConfigurationNames(), destroyed)
+	destroy(a.instances, a.configurableFactory.Names(), destroyed)
+}
+
+// destroy calls the destroy hooks of the items in container in the reverse order of names
+func destroy(container cmap.ConcurrentMap, names []string, destroyed map[interface{}]bool) {
+	for i := len(names) - 1; i >= 0; i-- {
+		item, _ := container.Get(names[i])
+		destroyer, ok := item.(Destroyer)
+		if !ok {
+			continue
+		}
+		// the same instance may be saved in different names
+		if reflect.TypeOf(destroyer).Comparable() {
+			if destroyed[destroyer] {
+				continue
+			}
+			destroyed[destroyer] = true
+		}
+		log.Debugf("destroy %v", names[i])
+		destroyer.Destroy()
+	}
+}
+
 // GetInstance get application instance by name
 func (a *BaseApplication) GetInstance(name string) (instance interface{}) {
 	if a.configurableFactory != nil {
@@ -160,3 +160,79 @@ func TestBaseApplication(t *testing.T) {
 	ba.GetInstance("foo")
 
 }
+
+func TestBaseApplicationWithConfigLocation(t *testing.T) {
+	ba := new(app.BaseApplication)
+	ba.SetProperty(app.PropertyConfigPath, []string{"config-does-not-exist", "config"}).
+		SetProperty(app.PropertyConfigName, "application")
+
+	err := ba.Initialize()
+	assert.Equal(t, nil, err)
+
+	t.Run("should keep the properties that are set before initialization", func(t *testing.T) {
+		name, ok := ba.GetProperty(app.PropertyConfigName)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "application", name)
+	})
+
+	t.Run("should build system config from the search paths", func(t *testing.T) {
+		sc := ba.SystemConfig()
+		assert.NotEqual(t, nil, sc)
+	})
+}
+
+type destroyable struct {
+	destroyed int
+}
+
+func (d *destroyable) Destroy() {
+	d.destroyed++
+}
+
+func TestDestroy(t *testing.T) {
+	ba := new(app.BaseApplication)
+	err := ba.Initialize()
+	assert.Equal(t, nil, err)
+	d := new(destroyable)
+	f := ba.ConfigurableFactory()
+	f.SetInstance("foo", d)
+	f.SetInstance("bar", d)
+
+	t.Run("should call destroy hook once", func(t *testing.T) {
+		ba.Destroy()
+		assert.Equal(t, 1, d.destroyed)
+	})
+}
+
+type orderedDestroyable struct {
+	name      string
+	destroyed *[]string
+}
+
+func (d *orderedDestroyable) Destroy() {
+	*d.destroyed = append(*d.destroyed, d.name)
+}
+
+func TestDestroyInReverseOrderOfCreation(t *testing.T) {
+	ba := new(app.BaseApplication)
+	err := ba.Initialize()
+	assert.Equal(t, nil, err)
+	var destroyed []string
+	f := ba.ConfigurableFactory()
+	for _, name := range []string{"db", "repository", "service"} {
+		f.SetInstance(name, &orderedDestroyable{name: name, destroyed: &destroyed})
+	}
+
+	t.Run("should destroy the instances before their dependencies", func(t *testing.T) {
+		ba.Destroy()
+		assert.Equal(t, []string{"service", "repository", "db"}, destroyed)
+	})
+}
+
+func TestReadiness(t *testing.T) {
+	assert.Equal(t, true, app.IsReady())
+	app.SetReady(false)
+	assert.Equal(t, false, app.IsReady())
+	app.SetReady(true)
+	assert.Equal(t, true, app.IsReady())
+}
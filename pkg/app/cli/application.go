@@ -16,6 +16,7 @@ package cli
 
 import (
 	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/factory/autoconfigure"
 	"github.com/hidevopsio/hiboot/pkg/inject"
 	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/hidevopsio/hiboot/pkg/utils/gotest"
@@ -110,6 +111,7 @@ func (a *application) build() error {
 	var root = a.Root()
 	inject.IntoObject(root)
 	Register(root)
+	a.addConfigFlags(root)
 	a.SetRoot(root)
 	if !gotest.IsRunning() {
 		a.Root().EmbeddedCommand().Use = basename
@@ -121,6 +123,21 @@ func (a *application) build() error {
 	return nil
 }
 
+// addConfigFlags let root command accept the config location flags, which are parsed by ConfigurableFactory
+func (a *application) addConfigFlags(root Command) {
+	flags := root.PersistentFlags()
+	usages := map[string]string{
+		autoconfigure.FlagConfigPath: "config search paths, separated by comma",
+		autoconfigure.FlagConfigName: "config file name without extension",
+		autoconfigure.FlagConfigFile: "optional config file outside of the search paths",
+	}
+	for name, usage := range usages {
+		if flags.Lookup(name) == nil {
+			flags.String(name, "", usage)
+		}
+	}
+}
+
 // SetRoot set root command
 func (a *application) SetRoot(root Command) {
 	a.root = root
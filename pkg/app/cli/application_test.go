@@ -16,7 +16,10 @@ package cli
 
 import (
 	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/utils/io"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -190,3 +193,31 @@ func TestAB(t *testing.T) {
 	b.Name = "bb"
 	b.Run("hello")
 }
+
+type configDemoCommand struct {
+	BaseCommand
+	Config *ConfigCommand `cmd:""`
+}
+
+func (c *configDemoCommand) Init() {
+	c.Use = "config-demo"
+}
+
+func TestConfigCommand(t *testing.T) {
+	os.Setenv("APP_CONFIG_PATH", filepath.Join(io.GetWorkDir(), "..", "..", "..", "config"))
+	defer os.Unsetenv("APP_CONFIG_PATH")
+	testApp := NewTestApplication(t, new(configDemoCommand))
+
+	t.Run("should print the effective configuration", func(t *testing.T) {
+		output, err := testApp.RunTest("config")
+		assert.Equal(t, nil, err)
+		assert.Contains(t, output, "app.name: ")
+	})
+
+	t.Run("should print the metadata of configuration", func(t *testing.T) {
+		output, err := testApp.RunTest("config", "metadata")
+		assert.Equal(t, nil, err)
+		assert.Contains(t, output, "server.port")
+		assert.Contains(t, output, "refresh interval of the property sources")
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/factory/autoconfigure"
+	"sort"
+	"text/tabwriter"
+)
+
+// ConfigCommand prints the effective configuration with the secrets masked, or the metadata of
+// all configuration properties by sub command metadata, it can be added to any command, e.g.
+//
+//	type rootCommand struct {
+//		cli.BaseCommand
+//		Config *cli.ConfigCommand `cmd:""`
+//	}
+type ConfigCommand struct {
+	BaseCommand
+	configurableFactory *autoconfigure.ConfigurableFactory
+}
+
+func (c *ConfigCommand) Init(configurableFactory *autoconfigure.ConfigurableFactory) {
+	c.configurableFactory = configurableFactory
+	c.Use = "config"
+	c.Short = "print configuration"
+	c.Long = "Print the effective configuration, or the metadata of configuration properties by: config metadata"
+}
+
+func (c *ConfigCommand) Run(args []string) error {
+	props := c.configurableFactory.ConfigDump()
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(c.OutOrStdout(), "%v: %v\n", k, props[k])
+	}
+	return nil
+}
+
+func (c *ConfigCommand) OnMetadata(args []string) bool {
+	w := tabwriter.NewWriter(c.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tDEFAULT\tDESCRIPTION")
+	for _, m := range c.configurableFactory.Metadata() {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", m.Name, m.Type, m.DefaultValue, m.Description)
+	}
+	w.Flush()
+	return true
+}
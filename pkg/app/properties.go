@@ -16,4 +16,13 @@ package app
 
 const (
 	PropertyBannerDisabled = "property.banner.disabled"
+
+	// PropertyConfigPath is the config search paths, a string separated by comma or a []string
+	PropertyConfigPath = "property.config.path"
+
+	// PropertyConfigName is the config file name without extension, default is application
+	PropertyConfigName = "property.config.name"
+
+	// PropertyConfigFile is the optional config file outside of the search paths, e.g. /etc/config/application.yml
+	PropertyConfigFile = "property.config.file"
 )
@@ -0,0 +1,35 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import "sync/atomic"
+
+// notReady is 1 if the application is not ready to accept traffic, e.g. it is shutting down
+var notReady int32
+
+// SetReady set the readiness of application, the readiness is down during graceful shutdown,
+// so that no new traffic is routed to it
+func SetReady(ready bool) {
+	var v int32
+	if !ready {
+		v = 1
+	}
+	atomic.StoreInt32(&notReady, v)
+}
+
+// IsReady check if the application is ready to accept traffic
+func IsReady() bool {
+	return atomic.LoadInt32(&notReady) == 0
+}
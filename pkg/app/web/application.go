@@ -15,18 +15,27 @@
 package web
 
 import (
+	stdcontext "context"
 	"errors"
 	"fmt"
 	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/factory/autoconfigure"
 	"github.com/hidevopsio/hiboot/pkg/inject"
 	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/system"
 	"github.com/hidevopsio/hiboot/pkg/utils/io"
 	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
+	"github.com/hidevopsio/hiboot/pkg/utils/validator"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/view"
+	"net/http"
 	"os"
+	"os/signal"
 	"reflect"
 	"regexp"
+	"syscall"
+	"time"
 )
 
 const (
@@ -34,6 +43,8 @@ const (
 
 	beforeMethod = "Before"
 	afterMethod  = "After"
+
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 // Application is the struct of web Application
@@ -46,6 +57,14 @@ type application struct {
 	controllers   []interface{}
 	dispatcher    dispatcher
 	controllerMap map[string][]interface{}
+	// redirectServer redirects http requests to https
+	redirectServer *http.Server
+	// multipart is the size limits of multipart requests
+	multipart *multipartLimits
+	// etag responds the weak ETag of json responses
+	etag bool
+	// errorMappers are the error mappers of the components in the container
+	errorMappers []ErrorMapper
 }
 
 var (
@@ -83,8 +102,76 @@ func (a *application) Run() (err error) {
 	if err != nil {
 		return
 	}
+	// the destroy hooks are called whenever the server stops, e.g. the port is in use or it is not shut down gracefully
+	defer a.Destroy()
+
+	runner := iris.Addr(serverPort)
+	if conf != nil && conf.Server.TLS.Enabled {
+		runner, err = a.tlsRunner(serverPort, conf.Server.TLS)
+		if err != nil {
+			return
+		}
+		// the redirect server is closed when the application stops, even if it is not shut down gracefully
+		defer a.closeRedirectServer()
+	}
+
+	configuration := defaultConfiguration()
+	if conf == nil || !conf.Server.Shutdown.IsGraceful() {
+		err = a.webApp.Run(runner, iris.WithConfiguration(configuration))
+		return
+	}
+
+	// shutdown gracefully instead of the interrupt handler of iris
+	configuration.DisableInterruptHandler = true
+	done := make(chan error, 1)
+	stop := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sig)
+		select {
+		case s := <-sig:
+			log.Infof("Shutting down on signal %v", s)
+			done <- a.shutdown(conf.Server.Shutdown)
+		case <-stop:
+			// the server stops without signal, e.g. the port is in use
+			done <- nil
+		}
+	}()
 
-	err = a.webApp.Run(iris.Addr(fmt.Sprintf(serverPort)), iris.WithConfiguration(defaultConfiguration()))
+	err = a.webApp.Run(runner, iris.WithConfiguration(configuration))
+	close(stop)
+	// wait for the active requests if the server is shut down on signal
+	if e := <-done; err == iris.ErrServerClosed {
+		err = e
+	}
+	return
+}
+
+// shutdown marks readiness down, stops accepting new requests and waits for the active requests to complete
+// within the timeout, the destroy hooks of instances are called by Run once it returns
+func (a *application) shutdown(conf system.Shutdown) (err error) {
+	app.SetReady(false)
+	if delay, e := time.ParseDuration(conf.Delay); e == nil && delay > 0 {
+		time.Sleep(delay)
+	}
+
+	timeout, e := time.ParseDuration(conf.Timeout)
+	if e != nil || timeout <= 0 {
+		log.Warnf("invalid shutdown timeout: %v, the default timeout %v is used", conf.Timeout, defaultShutdownTimeout)
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), timeout)
+	defer cancel()
+	if a.redirectServer != nil {
+		a.redirectServer.Shutdown(ctx)
+	}
+	err = a.webApp.Shutdown(ctx)
+	if err != nil {
+		log.Errorf("failed to drain the active requests: %v", err)
+	}
+
+	log.Info("Application is shut down")
 	return
 }
 
@@ -118,21 +205,43 @@ func (a *application) build(controllers ...interface{}) (err error) {
 		log.Infof("Starting Hiboot web application %v on localhost with PID %v", systemConfig.App.Name, os.Getpid())
 		log.Infof("Working directory: %v", a.WorkDir)
 		log.Infof("The following profiles are active: %v, %v", systemConfig.App.Profiles.Active, systemConfig.App.Profiles.Include)
+		a.dispatcher.wrapResponse = systemConfig.Server.WrapResponse
+		a.dispatcher.webSocket = systemConfig.Server.WebSocket
+		a.dispatcher.versioning = systemConfig.Server.Versioning
+		a.etag = systemConfig.Server.ETag
+		a.multipart, err = newMultipartLimits(systemConfig.Server.Multipart)
+		if err != nil {
+			return
+		}
 	}
 
 	f := a.ConfigurableFactory()
 	f.SetInstance("applicationContext", a)
+	a.dispatcher.factory = f
+
+	// the request id is read or generated before other middleware, so that they can log with it, the panics of
+	// the handlers are recovered and logged with the request id as well
+	a.Use(requestIDHandler, recoverHandler)
 
 	// build auto configurations
 	a.BuildConfigurations()
 
+	// register the custom validations that are registered as components
+	a.registerValidations()
+
+	// the error mappers that are registered as components take precedence over the global ones
+	a.errorMappers = errorMappersOf(a.ConfigurableFactory().Items())
+
 	// The only one Required:
 	// here is how you define how your own context will
 	// be created and acquired from the iris' generic context pool.
 	a.webApp.ContextPool.Attach(func() context.Context {
 		return &Context{
 			// Optional Part 3:
-			Context: context.NewContext(a.webApp),
+			Context:      context.NewContext(a.webApp),
+			multipart:    a.multipart,
+			etag:         a.etag,
+			errorMappers: a.errorMappers,
 		}
 	})
 
@@ -145,12 +254,39 @@ func (a *application) build(controllers ...interface{}) (err error) {
 
 	// first register anon controllers
 	a.RegisterController(new(AnonController))
+	a.RegisterController(new(AnonWebSocketController))
+
+	// the OpenAPI document is generated on the first request, so that it includes the controllers registered later
+	if systemConfig != nil && systemConfig.OpenAPI.Enabled {
+		a.serveOpenAPI(systemConfig.OpenAPI)
+	}
+
+	// the static assets are served before the routes, and the responses of both are compressed
+	if systemConfig != nil {
+		if err = a.serveStatic(systemConfig.Server.Static); err != nil {
+			return
+		}
+		if err = a.compress(systemConfig.Server.Compression); err != nil {
+			return
+		}
+	}
 
 	// call AfterInitialization with factory interface
 	a.AfterInitialization()
 	return err
 }
 
+// registerValidations registers the instances that implement validator.Validation on validator.Validate
+func (a *application) registerValidations() {
+	for _, instance := range a.ConfigurableFactory().Items() {
+		if validation, ok := instance.(validator.Validation); ok {
+			if err := validator.Register(validation); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
 // RegisterController register controller, e.g. web.Controller, jwt.Controller, or other customized controller
 func (a *application) RegisterController(controller interface{}) error {
 	// get from controller map
@@ -161,7 +297,11 @@ func (a *application) RegisterController(controller interface{}) error {
 	}
 	controllers, ok := a.controllerMap[controllerInterfaceName]
 	if ok {
-		return a.dispatcher.register(a.webApp, controllers)
+		err := a.dispatcher.register(a.webApp, controllers)
+		if err != nil {
+			log.Error(err)
+		}
+		return err
 	}
 	return ErrControllersNotFound
 }
@@ -174,8 +314,34 @@ func (a *application) Use(handlers ...context.Handler) {
 	}
 }
 
+// OptionsResponder is implemented by the web application that responds to the OPTIONS requests of the paths of
+// controllers, it is enabled by the middleware that handles the preflight requests such as cors
+type OptionsResponder interface {
+	RespondOptions()
+}
+
+// RespondOptions responds to the OPTIONS requests of the paths of the controllers that are registered after it,
+// if the controllers do not handle OPTIONS, so that the middleware such as cors can handle the preflight requests
+func (a *application) RespondOptions() {
+	a.dispatcher.respondOptions = true
+}
+
+// ViewRegistry is implemented by the web application that renders the view models returned by controller methods,
+// it is not a method of app.ApplicationContext so that the other implementations of it are not affected
+type ViewRegistry interface {
+	RegisterView(engine view.Engine)
+}
+
+// RegisterView registers the template engine that renders the view models returned by controller methods
+func (a *application) RegisterView(engine view.Engine) {
+	a.webApp.RegisterView(engine)
+}
+
 func (a *application) initialize(controllers ...interface{}) (err error) {
-	io.EnsureWorkDir(3, "config/application.yml")
+	paths, name, _ := a.ConfigLocation()
+	if configFiles, ok := autoconfigure.LocalConfigFiles(paths, name); ok {
+		io.EnsureWorkDir(3, configFiles...)
+	}
 
 	// new iris app
 	a.webApp = iris.New()
@@ -25,10 +25,17 @@ import (
 	"github.com/hidevopsio/hiboot/pkg/starter/jwt"
 	_ "github.com/hidevopsio/hiboot/pkg/starter/locale"
 	_ "github.com/hidevopsio/hiboot/pkg/starter/logging"
+	"github.com/hidevopsio/hiboot/pkg/system"
 	"github.com/hidevopsio/hiboot/pkg/utils/io"
 	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
+	"github.com/kataras/iris/context"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -267,6 +274,663 @@ func (c *HelloController) GetAll() {
 	c.Ctx.ResponseBody("success", data)
 }
 
+// OrderController maps its methods to routes explicitly
+type OrderController struct {
+	web.Controller
+}
+
+func (c *OrderController) RequestMapping() map[string]string {
+	return map[string]string{
+		"ListOrders": "GET /{id}/orders",
+		"GetOrder":   "/{id}/orders/{orderId}",
+		"CancelAll":  "POST /{id}/cancel-all",
+		"Search":     "GET /{id}/search?status&page",
+	}
+}
+
+func (c *OrderController) Search(id int, status string, page int) string {
+	return fmt.Sprintf("%v orders of %v on page %v", status, id, page)
+}
+
+func (c *OrderController) ListOrders(id int) string {
+	return fmt.Sprintf("orders of %v", id)
+}
+
+func (c *OrderController) GetOrder(id int, orderID string) string {
+	return fmt.Sprintf("order %v of %v", orderID, id)
+}
+
+func (c *OrderController) CancelAll(id int) string {
+	return fmt.Sprintf("orders of %v are cancelled", id)
+}
+
+// GetByName is still mapped by naming convention
+func (c *OrderController) GetByName(name string) string {
+	return "order " + name
+}
+
+func TestRequestMapping(t *testing.T) {
+	wta := web.NewTestApplication(t, new(OrderController))
+
+	t.Run("should map method to route with path params", func(t *testing.T) {
+		wta.Get("/order/{id}/orders").
+			WithPath("id", 123).
+			Expect().Status(http.StatusOK).
+			Body().Equal("orders of 123")
+	})
+
+	t.Run("should map method to route without http method", func(t *testing.T) {
+		wta.Get("/order/{id}/orders/{orderId}").
+			WithPath("id", 123).
+			WithPath("orderId", "a-01").
+			Expect().Status(http.StatusOK).
+			Body().Equal("order a-01 of 123")
+	})
+
+	t.Run("should map method to route with hyphenated segment", func(t *testing.T) {
+		wta.Post("/order/{id}/cancel-all").
+			WithPath("id", 123).
+			Expect().Status(http.StatusOK).
+			Body().Equal("orders of 123 are cancelled")
+	})
+
+	t.Run("should keep naming convention", func(t *testing.T) {
+		wta.Get("/order/name/{name}").
+			WithPath("name", "foo").
+			Expect().Status(http.StatusOK).
+			Body().Equal("order foo")
+	})
+
+	t.Run("should bind the named params from query, header and cookie", func(t *testing.T) {
+		wta.Get("/order/{id}/search").
+			WithPath("id", 123).
+			WithQuery("status", "paid").
+			WithHeader("page", "2").
+			Expect().Status(http.StatusOK).
+			Body().Equal("paid orders of 123 on page 2")
+
+		wta.Get("/order/{id}/search").
+			WithPath("id", 123).
+			WithCookie("status", "open").
+			Expect().Status(http.StatusOK).
+			Body().Equal("open orders of 123 on page 0")
+	})
+
+	t.Run("should respond bad request if the param can not be converted", func(t *testing.T) {
+		wta.Get("/order/{id}/search").
+			WithPath("id", 123).
+			WithQuery("page", "first").
+			Expect().Status(http.StatusBadRequest)
+
+		wta.Get("/order/{id}/orders").
+			WithPath("id", "abc").
+			Expect().Status(http.StatusBadRequest)
+	})
+}
+
+type ProfileHeader struct {
+	model.RequestHeader
+	Authorization string
+	RequestID     string `header:"X-Request-ID"`
+}
+
+type ProfileRequest struct {
+	model.RequestParams
+	Page    int    `query:"page"`
+	Session string `cookie:"session"`
+}
+
+type ProfileController struct {
+	web.Controller
+}
+
+func (c *ProfileController) Get(header *ProfileHeader, request *ProfileRequest) string {
+	return fmt.Sprintf("%v %v page %v of %v", header.RequestID, header.Authorization, request.Page, request.Session)
+}
+
+func TestRequestBinding(t *testing.T) {
+	wta := web.NewTestApplication(t, new(ProfileController))
+
+	t.Run("should bind query, header and cookie", func(t *testing.T) {
+		wta.Get("/profile").
+			WithQuery("page", 2).
+			WithHeader("Authorization", "Bearer xyz").
+			WithHeader("X-Request-ID", "abc").
+			WithCookie("session", "s01").
+			Expect().Status(http.StatusOK).
+			Body().Equal("abc Bearer xyz page 2 of s01")
+	})
+
+	t.Run("should response 400 if failed to convert parameter", func(t *testing.T) {
+		wta.Get("/profile").
+			WithQuery("page", "two").
+			Expect().Status(http.StatusBadRequest)
+	})
+}
+
+type ErrorController struct {
+	web.Controller
+}
+
+func (c *ErrorController) GetNotFound() error {
+	return &system.ErrNotFound{}
+}
+
+func (c *ErrorController) GetConflict() (string, error) {
+	return "", &system.ErrConflict{Name: "user"}
+}
+
+func (c *ErrorController) GetForbidden() (model.Response, error) {
+	return new(model.BaseResponse), &system.ErrForbidden{Message: "access denied"}
+}
+
+func (c *ErrorController) GetPreset() (model.Response, error) {
+	response := new(model.BaseResponse)
+	response.SetCode(http.StatusServiceUnavailable)
+	return response, &system.ErrForbidden{Message: "access denied"}
+}
+
+func (c *ErrorController) GetCustom() (string, error) {
+	return "", errCustom
+}
+
+func (c *ErrorController) GetInternal() (string, error) {
+	return "", errors.New("dial tcp 10.0.0.1:5432: connection refused")
+}
+
+var errCustom = errors.New("custom error")
+
+type customErrorMapping struct{}
+
+func newCustomErrorMapping() *customErrorMapping {
+	return &customErrorMapping{}
+}
+
+func (m *customErrorMapping) MapError(err error) (status int, code string, message string, ok bool) {
+	if err == errCustom {
+		return http.StatusTeapot, "custom", err.Error(), true
+	}
+	return
+}
+
+func TestErrorMapping(t *testing.T) {
+	app.Component(newCustomErrorMapping)
+	wta := web.NewTestApplication(t, new(ErrorController))
+
+	t.Run("should map error to 404", func(t *testing.T) {
+		wta.Get("/error/notFound").
+			Expect().Status(http.StatusNotFound).
+			JSON().Object().ValueEqual("error_code", "not_found")
+	})
+
+	t.Run("should map error of (string, error) to 409", func(t *testing.T) {
+		wta.Get("/error/conflict").
+			Expect().Status(http.StatusConflict).
+			JSON().Object().ValueEqual("error_code", "conflict")
+	})
+
+	t.Run("should map error of (model.Response, error) to 403", func(t *testing.T) {
+		wta.Get("/error/forbidden").
+			Expect().Status(http.StatusForbidden).
+			JSON().Object().ValueEqual("message", "access denied")
+	})
+
+	t.Run("should keep the code set by controller", func(t *testing.T) {
+		wta.Get("/error/preset").
+			Expect().Status(http.StatusServiceUnavailable).
+			JSON().Object().ValueEqual("error_code", "forbidden")
+	})
+
+	t.Run("should map error by custom error mapper", func(t *testing.T) {
+		wta.Get("/error/custom").
+			Expect().Status(http.StatusTeapot).
+			JSON().Object().ValueEqual("error_code", "custom")
+	})
+
+	t.Run("should not reveal the internal server error", func(t *testing.T) {
+		wta.Get("/error/internal").
+			Expect().Status(http.StatusInternalServerError).
+			Body().NotContains("10.0.0.1")
+	})
+}
+
+type Book struct {
+	Title  string `json:"title" xml:"title" yaml:"title"`
+	Author string `json:"author" xml:"author" yaml:"author"`
+}
+
+type BookController struct {
+	web.Controller
+}
+
+func (c *BookController) GetById(id int) (*Book, error) {
+	if id == 0 {
+		return nil, &system.ErrNotFound{}
+	}
+	return &Book{Title: "Hiboot", Author: "John Deng"}, nil
+}
+
+func (c *BookController) Get() []Book {
+	return []Book{{Title: "Hiboot", Author: "John Deng"}}
+}
+
+func (c *BookController) GetStats() model.Response {
+	response := new(model.BaseResponse)
+	response.SetData(map[string]int{"total": 1})
+	return response
+}
+
+func (c *BookController) GetMissing() (*model.BaseResponse, error) {
+	return nil, &system.ErrNotFound{}
+}
+
+// Response is a user type that is named Response but does not implement model.Response
+type Response struct {
+	Status string `json:"status"`
+}
+
+func (c *BookController) GetStatus() Response {
+	return Response{Status: "available"}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	wta := web.NewTestApplication(t, new(BookController))
+
+	t.Run("should response the struct named Response that is not model.Response", func(t *testing.T) {
+		wta.Get("/book/status").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("status", "available")
+	})
+
+	t.Run("should response struct in json by default", func(t *testing.T) {
+		wta.Get("/book/id/1").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("title", "Hiboot")
+	})
+
+	t.Run("should response slice in json", func(t *testing.T) {
+		wta.Get("/book").
+			WithHeader("Accept", "application/json").
+			Expect().Status(http.StatusOK).
+			JSON().Array().Length().Equal(1)
+	})
+
+	t.Run("should response struct in xml", func(t *testing.T) {
+		wta.Get("/book/id/1").
+			WithHeader("Accept", "application/xml").
+			Expect().Status(http.StatusOK).
+			Body().Equal("<Book><title>Hiboot</title><author>John Deng</author></Book>")
+	})
+
+	t.Run("should response slice in yaml", func(t *testing.T) {
+		wta.Get("/book").
+			WithHeader("Accept", "application/x-yaml").
+			Expect().Status(http.StatusOK).
+			Body().Equal("- title: Hiboot\n  author: John Deng\n")
+	})
+
+	t.Run("should response error of (T, error)", func(t *testing.T) {
+		wta.Get("/book/id/0").
+			Expect().Status(http.StatusNotFound)
+	})
+
+	t.Run("should response json to browsers", func(t *testing.T) {
+		wta.Get("/book/stats").
+			WithHeader("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8").
+			Expect().Status(http.StatusOK).
+			JSON().Object().Value("data").Object().ValueEqual("total", 1)
+	})
+
+	t.Run("should response json if the data can not be encoded in xml", func(t *testing.T) {
+		wta.Get("/book/stats").
+			WithHeader("Accept", "application/xml").
+			Expect().Status(http.StatusOK).
+			JSON().Object().Value("data").Object().ValueEqual("total", 1)
+	})
+
+	t.Run("should response the error of nil *model.BaseResponse", func(t *testing.T) {
+		wta.Get("/book/missing").
+			Expect().Status(http.StatusNotFound)
+	})
+}
+
+type prefixValidation struct{}
+
+func newPrefixValidation() *prefixValidation {
+	return &prefixValidation{}
+}
+
+func (v *prefixValidation) Tag() string {
+	return "prefix"
+}
+
+func (v *prefixValidation) Validate(field reflect.Value, param string) bool {
+	return strings.HasPrefix(field.String(), param)
+}
+
+type GreetingRequest struct {
+	model.RequestBody
+	Message string `validate:"prefix=hi"`
+}
+
+func TestConditionalRequest(t *testing.T) {
+	wta := web.NewTestApplication(t, new(BookController))
+
+	t.Run("should not respond the etag by default", func(t *testing.T) {
+		wta.Get("/book/1").
+			Expect().Status(http.StatusOK).
+			Header("ETag").Empty()
+	})
+}
+
+type GreetingController struct {
+	web.Controller
+}
+
+func (c *GreetingController) Post(request *GreetingRequest) string {
+	return request.Message
+}
+
+func TestCustomValidation(t *testing.T) {
+	app.Component(newPrefixValidation)
+	wta := web.NewTestApplication(t, new(GreetingController))
+
+	t.Run("should pass custom validation", func(t *testing.T) {
+		wta.Post("/greeting").
+			WithJSON(&GreetingRequest{Message: "hi, there"}).
+			Expect().Status(http.StatusOK).
+			Body().Equal("hi, there")
+	})
+
+	t.Run("should fail custom validation", func(t *testing.T) {
+		wta.Post("/greeting").
+			WithJSON(&GreetingRequest{Message: "hello"}).
+			Expect().Status(http.StatusBadRequest).
+			JSON().Object().Value("data").Array().Element(0).Object().ValueEqual("rule", "prefix")
+	})
+}
+
+type middlewareConfiguration struct {
+	app.Configuration
+}
+
+func newMiddlewareConfiguration() *middlewareConfiguration {
+	return &middlewareConfiguration{}
+}
+
+func (c *middlewareConfiguration) AuthMiddleware() context.Handler {
+	return func(ctx context.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.StatusCode(http.StatusUnauthorized)
+			ctx.StopExecution()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func (c *middlewareConfiguration) TraceMiddleware() context.Handler {
+	return func(ctx context.Context) {
+		ctx.Header("X-Trace", "traced")
+		ctx.Next()
+	}
+}
+
+type NoteController struct {
+	web.Controller
+}
+
+func (c *NoteController) Middleware() map[string][]string {
+	return map[string][]string{
+		"*":     {"traceMiddleware"},
+		"Post*": {"authMiddleware"},
+	}
+}
+
+func (c *NoteController) Get() string {
+	return "notes"
+}
+
+func (c *NoteController) Post() string {
+	return "created"
+}
+
+func TestControllerMiddleware(t *testing.T) {
+	app.AutoConfiguration(newMiddlewareConfiguration)
+	wta := web.NewTestApplication(t, new(NoteController))
+
+	t.Run("should apply middleware of all methods", func(t *testing.T) {
+		wta.Get("/note").
+			Expect().Status(http.StatusOK).
+			Header("X-Trace").Equal("traced")
+	})
+
+	t.Run("should reject the request by the middleware of Post methods", func(t *testing.T) {
+		wta.Post("/note").
+			Expect().Status(http.StatusUnauthorized)
+	})
+
+	t.Run("should pass the middleware of Post methods", func(t *testing.T) {
+		wta.Post("/note").
+			WithHeader("Authorization", "Bearer xyz").
+			Expect().Status(http.StatusOK).
+			Body().Equal("created")
+	})
+}
+
+type UploadRequest struct {
+	model.RequestMultipart
+	Title string                `validate:"required"`
+	File  *multipart.FileHeader `form:"file" validate:"required"`
+}
+
+type UploadController struct {
+	web.Controller
+}
+
+func (c *UploadController) Post(request *UploadRequest) (string, error) {
+	f, err := request.File.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	content, err := ioutil.ReadAll(f)
+	return fmt.Sprintf("%v:%v:%v", request.Title, request.File.Filename, string(content)), err
+}
+
+func TestMultipartUpload(t *testing.T) {
+	wta := web.NewTestApplication(t, new(UploadController))
+
+	t.Run("should upload file with form values", func(t *testing.T) {
+		wta.Post("/upload").
+			WithMultipart().
+			WithFormField("title", "hiboot").
+			WithFileBytes("file", "hello.txt", []byte("hello")).
+			Expect().Status(http.StatusOK).
+			Body().Equal("hiboot:hello.txt:hello")
+	})
+
+	t.Run("should validate the uploaded file", func(t *testing.T) {
+		wta.Post("/upload").
+			WithMultipart().
+			WithFormField("title", "hiboot").
+			Expect().Status(http.StatusBadRequest)
+	})
+}
+
+type StreamController struct {
+	web.Controller
+}
+
+// GetEvents streams the server-sent events
+func (c *StreamController) GetEvents() <-chan model.Event {
+	events := make(chan model.Event)
+	go func() {
+		defer close(events)
+		for i := 1; i <= 3; i++ {
+			events <- model.Event{ID: fmt.Sprintf("%d", i), Data: "tick"}
+		}
+	}()
+	return events
+}
+
+// GetDownload streams the chunked download
+func (c *StreamController) GetDownload() *strings.Reader {
+	c.Ctx.ContentType("text/csv")
+	return strings.NewReader("id,name\n1,hiboot\n")
+}
+
+func TestStreamingResponse(t *testing.T) {
+	wta := web.NewTestApplication(t, new(StreamController))
+
+	t.Run("should stream server-sent events", func(t *testing.T) {
+		wta.Get("/stream/events").
+			Expect().Status(http.StatusOK).
+			ContentType("text/event-stream").
+			Body().Equal("id: 1\ndata: tick\n\nid: 2\ndata: tick\n\nid: 3\ndata: tick\n\n")
+	})
+
+	t.Run("should stream chunked download", func(t *testing.T) {
+		wta.Get("/stream/download").
+			Expect().Status(http.StatusOK).
+			ContentType("text/csv").
+			Body().Equal("id,name\n1,hiboot\n")
+	})
+}
+
+// AccountV1Controller is the deprecated version of account api
+type AccountV1Controller struct {
+	web.Controller `version:"v1" sunset:"Sat, 01 Jun 2019 00:00:00 GMT"`
+	ContextMapping string `value:"/account"`
+}
+
+func (c *AccountV1Controller) Get() string {
+	return "account v1"
+}
+
+// AccountV2Controller registers the same route as AccountV1Controller for version v2
+type AccountV2Controller struct {
+	web.Controller `version:"v2"`
+	ContextMapping string `value:"/account"`
+}
+
+func (c *AccountV2Controller) Get() string {
+	return "account v2"
+}
+
+func TestAPIVersioning(t *testing.T) {
+	wta := web.NewTestApplication(t, new(AccountV1Controller), new(AccountV2Controller))
+
+	t.Run("should route by version prefix", func(t *testing.T) {
+		wta.Get("/v2/account").
+			Expect().Status(http.StatusOK).
+			Body().Equal("account v2")
+	})
+
+	t.Run("should respond deprecation headers of old version", func(t *testing.T) {
+		e := wta.Get("/v1/account").Expect().Status(http.StatusOK)
+		e.Body().Equal("account v1")
+		e.Header("Deprecation").Equal("true")
+		e.Header("Sunset").Equal("Sat, 01 Jun 2019 00:00:00 GMT")
+	})
+}
+
+type EchoController struct {
+	web.Controller
+}
+
+// Get echoes the header X-Echo by Ctx of the controller
+func (c *EchoController) Get() string {
+	time.Sleep(time.Millisecond)
+	return c.Ctx.GetHeader("X-Echo")
+}
+
+// GetContext echoes the header X-Echo by the ctx parameter
+func (c *EchoController) GetContext(ctx *web.Context) string {
+	return ctx.GetHeader("X-Echo")
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	wta := web.NewTestApplication(t, new(EchoController))
+
+	t.Run("should get the ctx of each request concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				echo := fmt.Sprintf("echo-%d", i)
+				wta.Get("/echo").
+					WithHeader("X-Echo", echo).
+					Expect().Status(http.StatusOK).
+					Body().Equal(echo)
+				wta.Get("/echo/context").
+					WithHeader("X-Echo", echo).
+					Expect().Status(http.StatusOK).
+					Body().Equal(echo)
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+// RequestIDController responds the request id of the request
+type RequestIDController struct {
+	web.Controller
+}
+
+func (c *RequestIDController) Get() string {
+	c.Ctx.Log().Info("get request id")
+	c.Ctx.Log().Infof("request id: %v", c.Ctx.RequestID())
+	return c.Ctx.RequestID()
+}
+
+func TestRequestID(t *testing.T) {
+	wta := web.NewTestApplication(t, new(RequestIDController))
+
+	t.Run("should respond the request id from client", func(t *testing.T) {
+		e := wta.Get("/requestID").
+			WithHeader(web.RequestIDHeader, "f47ac10b").
+			Expect().Status(http.StatusOK)
+		e.Header(web.RequestIDHeader).Equal("f47ac10b")
+		e.Body().Equal("f47ac10b")
+	})
+
+	t.Run("should respond the request id that contains verbs", func(t *testing.T) {
+		e := wta.Get("/requestID").
+			WithHeader(web.RequestIDHeader, "%s%s%d").
+			Expect().Status(http.StatusOK)
+		e.Header(web.RequestIDHeader).Equal("%s%s%d")
+		e.Body().Equal("%s%s%d")
+	})
+
+	t.Run("should generate the request id", func(t *testing.T) {
+		wta.Get("/requestID").
+			Expect().Status(http.StatusOK).
+			Header(web.RequestIDHeader).NotEmpty()
+	})
+}
+
+// PanicController panics in the handler
+type PanicController struct {
+	web.Controller
+}
+
+func (c *PanicController) Get() string {
+	panic("unexpected")
+}
+
+func TestRecover(t *testing.T) {
+	wta := web.NewTestApplication(t, new(PanicController))
+
+	t.Run("should respond internal server error on panic", func(t *testing.T) {
+		wta.Get("/panic").
+			WithHeader(web.RequestIDHeader, "f47ac10b").
+			Expect().Status(http.StatusInternalServerError).
+			Header(web.RequestIDHeader).Equal("f47ac10b")
+	})
+}
+
 func TestWebApplication(t *testing.T) {
 	wta := web.NewTestApplication(t, newHelloController, new(FooController), new(BarController), new(FoobarController))
 
@@ -317,6 +981,18 @@ func TestWebApplication(t *testing.T) {
 			Expect().Status(http.StatusBadRequest)
 	})
 
+	t.Run("should response field errors of validation", func(t *testing.T) {
+		obj := wta.Post("/foo/login").
+			WithJSON(&UserRequest{Username: "johndoe"}).
+			Expect().Status(http.StatusBadRequest).
+			JSON().Object()
+		obj.ValueEqual("error_code", "validation_failed")
+		fieldError := obj.Value("data").Array().Element(0).Object()
+		fieldError.ValueEqual("field", "Password")
+		fieldError.ValueEqual("rule", "required")
+		fieldError.ValueEqual("message", "Password is required")
+	})
+
 	t.Run("should return success after POST /foo", func(t *testing.T) {
 		wta.Post("/foo").
 			WithJSON(&FooRequest{Name: "John"}).
@@ -0,0 +1,227 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
+	"github.com/hidevopsio/hiboot/pkg/utils/str"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	tagQuery  = "query"
+	tagHeader = "header"
+	tagCookie = "cookie"
+
+	tagMapstructure = "mapstructure"
+)
+
+var bindingTags = []string{tagQuery, tagHeader, tagCookie}
+
+// ErrUnsupportedType the type of field can not be bound from request values
+var ErrUnsupportedType = errors.New("[web] unsupported type")
+
+// valueLookup returns the values of the name in request
+type valueLookup func(r *http.Request, name string) (values []string, ok bool)
+
+var lookups = map[string]valueLookup{
+	// the query names are matched case insensitively as they were decoded by mapstructure
+	tagQuery: func(r *http.Request, name string) (values []string, ok bool) {
+		query := r.URL.Query()
+		if values, ok = query[name]; ok {
+			return
+		}
+		for k, v := range query {
+			if strings.EqualFold(k, name) {
+				return v, true
+			}
+		}
+		return
+	},
+	tagHeader: func(r *http.Request, name string) (values []string, ok bool) {
+		values, ok = r.Header[http.CanonicalHeaderKey(name)]
+		return
+	},
+	tagCookie: func(r *http.Request, name string) (values []string, ok bool) {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return
+		}
+		return []string{cookie.Value}, true
+	},
+}
+
+// lookupParam returns the values of the named param in query, header or cookie in order
+func lookupParam(r *http.Request, name string) (values []string) {
+	for _, tag := range bindingTags {
+		if values, ok := lookups[tag](r, name); ok {
+			return values
+		}
+	}
+	return
+}
+
+// hasBindingTags check if any field of the struct type is tagged with query, header or cookie
+func hasBindingTags(typ reflect.Type) bool {
+	typ = reflector.IndirectType(typ)
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for _, f := range reflector.DeepFields(typ) {
+		for _, tag := range bindingTags {
+			if _, ok := f.Tag.Lookup(tag); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bindValues binds the request values into the fields of data, the value name is given by the tag
+// or the field name if bindAll is true and the field is not tagged
+func bindValues(r *http.Request, data interface{}, tagName string, bindAll bool) error {
+	v := reflector.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	lookup := lookups[tagName]
+	for _, f := range reflector.DeepFields(v.Type()) {
+		if f.Anonymous || f.PkgPath != "" {
+			continue
+		}
+		name, ok := fieldName(f, tagName, bindAll)
+		if !ok {
+			continue
+		}
+		values, ok := lookup(r, name)
+		if !ok && name == f.Name {
+			values, ok = lookup(r, str.LowerFirst(name))
+		}
+		if !ok {
+			continue
+		}
+		field := v.FieldByName(f.Name)
+		if !field.CanSet() {
+			continue
+		}
+		if err := setValue(field, values); err != nil {
+			return &system.ErrInvalidParameter{Name: name, Value: strings.Join(values, ","), Type: f.Type.String()}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name of the field in request, which is given by the tag or the field name if bindAll is true
+// and the field is not tagged, it returns false if the field is not bound. The query param is named by the mapstructure
+// tag if the query tag is absent, as the query params were decoded by mapstructure
+func fieldName(f reflect.StructField, tagName string, bindAll bool) (name string, ok bool) {
+	name, ok = f.Tag.Lookup(tagName)
+	if !ok && bindAll && tagName == tagQuery {
+		name, ok = f.Tag.Lookup(tagMapstructure)
+	}
+	if !ok && !bindAll {
+		return
+	}
+	name = strings.Split(name, ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// bindTaggedValues binds the query, header and cookie values into the tagged fields
+func bindTaggedValues(r *http.Request, data interface{}) (err error) {
+	for _, tag := range bindingTags {
+		if err = bindValues(r, data, tag, false); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// setValue converts the values into the type of field then sets it, the slice is set by all values or
+// the comma separated value
+func setValue(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		v := reflect.New(field.Type().Elem())
+		if err := setValue(v.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+
+	var raw string
+	if len(values) != 0 {
+		raw = values[0]
+	}
+	value := strings.TrimSpace(raw)
+	if value == "" && field.Kind() != reflect.String {
+		// empty value is the zero value
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
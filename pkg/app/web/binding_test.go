@@ -0,0 +1,117 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type pageRequest struct {
+	model.RequestParams
+	Page    int      `query:"page"`
+	Size    *uint    `query:"size"`
+	Sort    []string `query:"sort"`
+	Keyword string
+	Ratio   float64
+	Token   string `header:"X-Token"`
+	Session string `cookie:"session"`
+	Ignored string `query:"-"`
+}
+
+type userHeader struct {
+	model.RequestHeader
+	Authorization string
+	RequestID     string `header:"X-Request-ID"`
+	Debug         bool
+}
+
+// legacyParams is named by mapstructure tags, as the query params were decoded by mapstructure
+type legacyParams struct {
+	model.RequestParams
+	PageSize int    `mapstructure:"page_size"`
+	OrderBy  string `mapstructure:"orderBy"`
+	Name     string
+}
+
+func TestBindValues(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?page=2&size=10&sort=name&sort=age&keyword=foo&ratio=0.5&Ignored=x", nil)
+	r.Header.Set("X-Token", "token")
+	r.Header.Set("Authorization", "Bearer xyz")
+	r.Header.Set("X-Request-ID", "abc")
+	r.Header.Set("Debug", "true")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s01"})
+
+	t.Run("should bind query values", func(t *testing.T) {
+		req := new(pageRequest)
+		err := bindValues(r, req, tagQuery, true)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, req.Page)
+		assert.Equal(t, uint(10), *req.Size)
+		assert.Equal(t, []string{"name", "age"}, req.Sort)
+		assert.Equal(t, "foo", req.Keyword)
+		assert.Equal(t, 0.5, req.Ratio)
+		assert.Equal(t, "", req.Ignored)
+	})
+
+	t.Run("should bind query values by mapstructure tags and case insensitive names", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?page_size=20&ORDERBY=age&NAME=foo", nil)
+		req := new(legacyParams)
+		err := bindValues(r, req, tagQuery, true)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 20, req.PageSize)
+		assert.Equal(t, "age", req.OrderBy)
+		assert.Equal(t, "foo", req.Name)
+	})
+
+	t.Run("should bind tagged header and cookie values", func(t *testing.T) {
+		req := new(pageRequest)
+		err := bindTaggedValues(r, req)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, req.Page)
+		assert.Equal(t, "", req.Keyword)
+		assert.Equal(t, "token", req.Token)
+		assert.Equal(t, "s01", req.Session)
+	})
+
+	t.Run("should bind header values", func(t *testing.T) {
+		req := new(userHeader)
+		err := bindValues(r, req, tagHeader, true)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Bearer xyz", req.Authorization)
+		assert.Equal(t, "abc", req.RequestID)
+		assert.Equal(t, true, req.Debug)
+	})
+
+	t.Run("should report invalid parameter", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?page=two", nil)
+		err := bindValues(r, new(pageRequest), tagQuery, true)
+		e, ok := err.(*system.ErrInvalidParameter)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "page", e.Name)
+		assert.Equal(t, "two", e.Value)
+	})
+
+	t.Run("should check binding tags", func(t *testing.T) {
+		assert.Equal(t, true, hasBindingTags(reflect.TypeOf(new(pageRequest))))
+		assert.Equal(t, false, hasBindingTags(reflect.TypeOf(new(FooController))))
+		assert.Equal(t, false, hasBindingTags(reflect.TypeOf(1)))
+	})
+}
@@ -0,0 +1,236 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+var errHijackNotSupported = errors.New("the response writer does not support hijack")
+
+// compression compresses the responses whose size is not less than minSize and whose content type has
+// one of the prefixes in types
+type compression struct {
+	minSize int64
+	level   int
+	types   []string
+}
+
+func newCompression(conf system.Compression) (*compression, error) {
+	minSize, err := parseSize(conf.MinSize)
+	if err != nil {
+		return nil, err
+	}
+	c := &compression{minSize: minSize, level: conf.Level, types: conf.Types}
+	if c.level == 0 {
+		c.level = flate.DefaultCompression
+	}
+	// validate the level, as the writers are created on requests
+	if _, err = flate.NewWriter(nil, c.level); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// encoding returns the encoding accepted by the request, or empty string if the response is not compressed
+func (c *compression) encoding(r *http.Request) string {
+	if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+		return ""
+	}
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		if acceptsEncoding(r, encoding) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressible reports whether the response of content type is compressed, the partial content is not compressed
+// as the range is of the uncompressed content
+func (c *compression) compressible(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	for _, prefix := range c.types {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// serve routes the request with the writer that compresses the response if the client accepts the encoding
+func (c *compression) serve(w http.ResponseWriter, r *http.Request, router http.HandlerFunc) {
+	// the response varies by Accept-Encoding even if it is not compressed, otherwise the shared caches may serve
+	// the uncompressed copy to the clients that accept gzip, or the compressed copy to the ones that do not
+	if r.Header.Get("Upgrade") == "" {
+		addVary(w.Header(), "Accept-Encoding")
+	}
+	encoding := c.encoding(r)
+	if encoding == "" {
+		router(w, r)
+		return
+	}
+	cw := &compressWriter{ResponseWriter: w, compression: c, encoding: encoding}
+	router(cw, r)
+	cw.close()
+}
+
+// compressWriter buffers the response until it reaches the min size, then compresses the rest of it, the response
+// that ends or is flushed before that is written as it is
+type compressWriter struct {
+	http.ResponseWriter
+	compression *compression
+	encoding    string
+	status      int
+	buf         []byte
+	decided     bool
+	writer      io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	// the responses without body are not compressed
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		header := w.Header()
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(append(w.buf, b...)))
+		}
+		if !w.compression.compressible(header) {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, b...)
+			if int64(len(w.buf)) < w.compression.minSize {
+				return len(b), nil
+			}
+			buffered := w.buf
+			w.buf = nil
+			w.decide(true)
+			if _, err := w.writer.Write(buffered); err != nil {
+				return 0, err
+			}
+			return len(b), nil
+		}
+	}
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide writes the header, and the buffered response as it is if it is not compressed
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	header := w.Header()
+	if compress {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+		if w.encoding == encodingGzip {
+			w.writer, _ = gzip.NewWriterLevel(w.ResponseWriter, w.compression.level)
+		} else {
+			w.writer, _ = flate.NewWriter(w.ResponseWriter, w.compression.level)
+		}
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if !compress && len(w.buf) != 0 {
+		w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// Flush flushes the compressed data, the response that is flushed before it reaches the min size is not
+// compressed, e.g. server-sent events
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hijacks the connection, e.g. websocket
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.decided = true
+		return h.Hijack()
+	}
+	return nil, nil, errHijackNotSupported
+}
+
+// close writes the buffered response, and the end of the compressed response
+func (w *compressWriter) close() error {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.writer != nil {
+		return w.writer.Close()
+	}
+	return nil
+}
+
+// addVary adds the request header name to Vary header unless it is added already
+func addVary(header http.Header, name string) {
+	for _, value := range header["Vary"] {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// compress compresses the responses of the routes and static assets
+func (a *application) compress(conf system.Compression) error {
+	if !conf.Enabled {
+		return nil
+	}
+	c, err := newCompression(conf)
+	if err != nil {
+		return err
+	}
+	a.webApp.WrapRouter(c.serve)
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	c, err := newCompression(system.Compression{
+		Enabled: true,
+		MinSize: "1KB",
+		Types:   []string{"text/", "application/json"},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, flate.DefaultCompression, c.level)
+
+	large := strings.Repeat(`{"name":"hiboot"}`, 100)
+	serve := func(acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		c.serve(w, r, handler)
+		return w
+	}
+	writeJSON := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			// write in chunks that are less than the min size
+			for i := 0; i < len(body); i += 100 {
+				end := i + 100
+				if end > len(body) {
+					end = len(body)
+				}
+				w.Write([]byte(body[i:end]))
+			}
+		}
+	}
+
+	t.Run("should compress the large response in gzip", func(t *testing.T) {
+		w := serve("gzip, deflate", writeJSON(large))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		gr, err := gzip.NewReader(w.Body)
+		assert.Equal(t, nil, err)
+		body, _ := ioutil.ReadAll(gr)
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("should compress the large response in deflate", func(t *testing.T) {
+		w := serve("deflate", writeJSON(large))
+		assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+		body, _ := ioutil.ReadAll(flate.NewReader(w.Body))
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("should not compress the small response", func(t *testing.T) {
+		w := serve("gzip", writeJSON(`{"name":"hiboot"}`))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"name":"hiboot"}`, w.Body.String())
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	})
+
+	t.Run("should not compress if the client does not accept the encoding", func(t *testing.T) {
+		w := serve("br", writeJSON(large))
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+		assert.Equal(t, []string{"Accept-Encoding"}, w.Header()["Vary"])
+	})
+
+	t.Run("should not add Vary header twice", func(t *testing.T) {
+		w := serve("gzip", writeJSON(large))
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, []string{"Accept-Encoding"}, w.Header()["Vary"])
+
+		header := http.Header{"Vary": {"Accept, accept-encoding"}}
+		addVary(header, "Accept-Encoding")
+		assert.Equal(t, []string{"Accept, accept-encoding"}, header["Vary"])
+	})
+
+	t.Run("should not compress the content type other than types", func(t *testing.T) {
+		w := serve("gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(large))
+		})
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("should not compress the encoded response", func(t *testing.T) {
+		w := serve("gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte(large))
+		})
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("should not compress the partial content", func(t *testing.T) {
+		w := serve("gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Range", "bytes 0-1699/3400")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(large))
+		})
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("should not compress the response that is flushed", func(t *testing.T) {
+		w := serve("gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("data: tick\n\n"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(large))
+		})
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, true, w.Flushed)
+		assert.Equal(t, "data: tick\n\n"+large, w.Body.String())
+	})
+
+	t.Run("should not compress the response without body", func(t *testing.T) {
+		w := serve("gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("should report invalid properties", func(t *testing.T) {
+		_, err := newCompression(system.Compression{MinSize: "1XB"})
+		assert.NotEqual(t, nil, err)
+		_, err = newCompression(system.Compression{Level: 10})
+		assert.NotEqual(t, nil, err)
+	})
+}
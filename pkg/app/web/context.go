@@ -15,23 +15,29 @@
 package web
 
 import (
-	"errors"
+	stdcontext "context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 
+	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/hidevopsio/hiboot/pkg/model"
-	"github.com/hidevopsio/hiboot/pkg/utils/mapstruct"
 	"github.com/hidevopsio/hiboot/pkg/utils/validator"
 	"github.com/kataras/iris/context"
 	"github.com/kataras/iris/middleware/i18n"
 )
 
+const errorCodeValidationFailed = "validation_failed"
+
 type ExtendedContext interface {
 	RequestEx(data interface{}, cb func() error) error
 	RequestBody(data interface{}) error
 	RequestForm(data interface{}) error
 	ResponseBody(message string, data interface{})
 	ResponseError(message string, code int)
+	ResponseErr(err error)
 }
 
 type ApplicationContext interface {
@@ -45,6 +51,18 @@ type Context struct {
 	// it's the context/context.go#context struct but you don't need to know it.
 	context.Context
 	ExtendedContext
+	// multipart is the size limits of multipart request
+	multipart *multipartLimits
+	// requestID is the id of the request that is read from or responded in X-Request-ID header
+	requestID string
+	// etag responds the weak ETag of json responses
+	etag bool
+	// errorMappers are the error mappers of the application
+	errorMappers []ErrorMapper
+	// controller is the copy of the singleton controller that serves the request, which is shared by its Before,
+	// the method and After, singleton is the pointer of the singleton
+	controller reflect.Value
+	singleton  uintptr
 }
 
 var _ context.Context = &Context{} // optionally: validate on compile-time if Context implements context.Context.
@@ -63,6 +81,39 @@ func (ctx *Context) Next() {
 	context.Next(ctx)
 }
 
+// BeginRequest resets the request id and the controller of the pooled context before the handlers of the request
+// are executed
+func (ctx *Context) BeginRequest(w http.ResponseWriter, r *http.Request) {
+	ctx.requestID = ""
+	ctx.controller, ctx.singleton = reflect.Value{}, 0
+	ctx.Context.BeginRequest(w, r)
+}
+
+// RequestID returns the id of the request
+func (ctx *Context) RequestID() string {
+	return ctx.requestID
+}
+
+// Log returns the logger that writes the request id in every log line
+func (ctx *Context) Log() *log.Logger {
+	return log.WithRequestID(ctx.requestID)
+}
+
+// Log returns the logger of the request id of ctx, the middleware that is given the iris context logs with it so that
+// their log lines carry the request id as well
+func Log(ctx context.Context) *log.Logger {
+	if c, ok := ctx.(*Context); ok {
+		return c.Log()
+	}
+	return log.WithRequestID("")
+}
+
+// RequestContext returns the context of the request that carries the request id, the gRPC clients forward
+// the request id in metadata if they are called with it
+func (ctx *Context) RequestContext() stdcontext.Context {
+	return log.NewContext(ctx.Request().Context(), ctx.requestID)
+}
+
 // HTML Override any context's method you want...
 // [...]
 func (ctx *Context) HTML(htmlContents string) (int, error) {
@@ -72,25 +123,65 @@ func (ctx *Context) HTML(htmlContents string) (int, error) {
 	return ctx.WriteString(htmlContents)
 }
 
-// RequestEx get RequestBody
+// RequestEx get request data by cb, then binds the fields tagged with query, header or cookie, and validates it
 func (ctx *Context) RequestEx(data interface{}, cb func() error) error {
 	if cb == nil {
 		return fmt.Errorf("callback func can't be nil")
 	}
 	err := cb()
+	if err == nil {
+		err = bindTaggedValues(ctx.Request(), data)
+	}
 	if err != nil {
-		ctx.ResponseError(err.Error(), http.StatusInternalServerError)
+		ctx.ResponseErr(err)
 		return err
 	}
 
 	err = validator.Validate.Struct(data)
 	if err != nil {
-		ctx.ResponseError(err.Error(), http.StatusBadRequest)
+		ctx.responseValidationErrors(data, err)
 		return err
 	}
 	return nil
 }
 
+// responseValidationErrors responses the failed fields and rules of data, the messages are translated by the key
+// validation.<rule>, e.g. validation.required = %[1]s is required, where %[1]s is the field and %[2]s is the param
+func (ctx *Context) responseValidationErrors(data interface{}, err error) {
+	fes := validator.FieldErrors(data, err)
+	if fes == nil {
+		ctx.ResponseError(err.Error(), http.StatusBadRequest)
+		return
+	}
+	fieldErrors := make([]model.FieldError, len(fes))
+	for i, fe := range fes {
+		fieldErrors[i] = model.FieldError{
+			Field:   fe.Field,
+			Rule:    fe.Rule,
+			Param:   fe.Param,
+			Message: fmt.Sprintf("Field validation for '%v' failed on the '%v' tag", fe.Field, fe.Rule),
+		}
+		key := "validation." + fe.Rule
+		msg := ctx.Translate(key)
+		// the rule is returned if the key is not found in language files
+		if msg != key && msg != fe.Rule && msg != "" {
+			if strings.Contains(msg, "%") {
+				msg = fmt.Sprintf(msg, fe.Field, fe.Param)
+			}
+			fieldErrors[i].Message = msg
+		}
+	}
+
+	response := new(model.BaseResponse)
+	response.SetCode(http.StatusBadRequest)
+	response.SetErrorCode(errorCodeValidationFailed)
+	response.SetMessage(ctx.translate(errorCodeValidationFailed))
+	response.SetData(fieldErrors)
+
+	ctx.StatusCode(http.StatusBadRequest)
+	ctx.JSON(response)
+}
+
 // RequestBody get RequestBody
 func (ctx *Context) RequestBody(data interface{}) error {
 
@@ -107,17 +198,35 @@ func (ctx *Context) RequestForm(data interface{}) error {
 	})
 }
 
+// RequestMultipart get RequestMultipart, the form values and uploaded files are bound into data
+func (ctx *Context) RequestMultipart(data interface{}) error {
+
+	return ctx.RequestEx(data, func() error {
+		return bindMultipart(ctx.ResponseWriter(), ctx.Request(), data, ctx.multipart)
+	})
+}
+
 // RequestParams get RequestParams
 func (ctx *Context) RequestParams(data interface{}) error {
 
 	return ctx.RequestEx(data, func() error {
+		return bindValues(ctx.Request(), data, tagQuery, true)
+	})
+}
 
-		values := ctx.URLParams()
-		if values == nil {
-			return errors.New("an empty form passed on ReadForm")
-		}
+// RequestHeader get RequestHeader
+func (ctx *Context) RequestHeader(data interface{}) error {
+
+	return ctx.RequestEx(data, func() error {
+		return bindValues(ctx.Request(), data, tagHeader, true)
+	})
+}
+
+// RequestCookie get RequestCookie
+func (ctx *Context) RequestCookie(data interface{}) error {
 
-		return mapstruct.Decode(data, values)
+	return ctx.RequestEx(data, func() error {
+		return bindValues(ctx.Request(), data, tagCookie, true)
 	})
 }
 
@@ -168,3 +277,68 @@ func (ctx *Context) ResponseError(message string, code int) {
 	ctx.StatusCode(code)
 	ctx.JSON(response)
 }
+
+// ResponseErr set response of err that is mapped to status code, error code and message by error mappers
+func (ctx *Context) ResponseErr(err error) {
+	code, errorCode, message := ctx.mapError(err)
+
+	response := new(model.BaseResponse)
+	response.SetCode(code)
+	response.SetErrorCode(errorCode)
+	response.SetMessage(ctx.translate(message))
+
+	ctx.StatusCode(code)
+	ctx.JSON(response)
+}
+
+// Negotiate writes data in the content type negotiated by Accept header, which is one of json, xml, yaml and protobuf
+func (ctx *Context) Negotiate(data interface{}) {
+	ctx.write(negotiate(ctx.GetHeader("Accept"), data), data)
+}
+
+// write writes data in the content type
+func (ctx *Context) write(contentType string, data interface{}) {
+	if contentType == contentTypeJSON && !ctx.etag {
+		ctx.JSON(data)
+		return
+	}
+	if contentType == contentTypeJSON {
+		body, err := json.Marshal(data)
+		if err != nil {
+			ctx.ResponseErr(err)
+			return
+		}
+		ctx.ContentType(contentType)
+		if !ctx.notModified(body) {
+			ctx.Write(body)
+		}
+		return
+	}
+	body, err := encode(contentType, data)
+	if err != nil {
+		// the data that can not be encoded in the negotiated type, e.g. a map in xml, is written in json
+		ctx.Log().Debugf("%v is written in json as it can not be encoded in %v: %v", reflect.TypeOf(data), contentType, err)
+		ctx.write(contentTypeJSON, data)
+		return
+	}
+	ctx.ContentType(contentType)
+	ctx.Write(body)
+}
+
+// notModified responds the weak ETag of the body, and 304 Not Modified if it matches If-None-Match header
+func (ctx *Context) notModified(body []byte) bool {
+	if method := ctx.Method(); method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	if status := ctx.GetStatusCode(); status != 0 && status != http.StatusOK {
+		return false
+	}
+	etag := weakETag(body)
+	ctx.Header("ETag", etag)
+	if !etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	ctx.ResponseWriter().Header().Del("Content-Type")
+	ctx.StatusCode(http.StatusNotModified)
+	return true
+}
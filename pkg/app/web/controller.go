@@ -14,12 +14,57 @@
 
 package web
 
+import "github.com/kataras/iris/context"
+
 // AnonController is an anonymous controller interface
 type AnonController interface{}
 
-// Controller is the web base controller
+// Controller is the web base controller, the controller is a singleton that is shallow copied once for each request
+// to hold its context in Ctx, and the copy is shared by Before, the method and After of the request, so that the
+// state set in Before is seen by the method. The state shared by requests should be kept by pointer fields, and the
+// locks such as sync.Mutex should be kept by pointer as they can not be copied, the controller that contains a lock by
+// value is kept as a singleton whose Ctx is shared by the concurrent requests as before.
+// Alternatively, the context can be injected by the method parameter of type *web.Context
 type Controller struct {
 	AnonController
 	ContextMapping string
 	Ctx            *Context
 }
+
+// RequestMapper is implemented by the controller that maps its methods to routes explicitly instead of
+// the naming convention, the key is the method name and the value is the route in the form of "METHOD /path",
+// e.g. "ListOrders": "GET /{id}/orders", the http method can be omitted if the method name starts with it,
+// e.g. "GetOrders": "/{id}/orders". The primitive arguments after the path params are named by the query in order,
+// and they are bound from query, header or cookie, e.g. "ListOrders": "GET /{id}/orders?status&page"
+type RequestMapper interface {
+	RequestMapping() map[string]string
+}
+
+// MiddlewareMapper is implemented by the controller that declares the middleware of its methods, the key is
+// the method name, the prefix of method names that ends with *, e.g. "Post*", or "*" for all methods, the value is
+// the ordered names of middleware instances in the container, which are context.Handler or implement Middleware.
+// The middleware of "*" are applied first, then the ones of prefixes and method name
+type MiddlewareMapper interface {
+	Middleware() map[string][]string
+}
+
+// UpgradeMiddlewareMapper is implemented by the base websocket controller that authenticates the upgrade requests,
+// e.g. jwt.WebSocketController, the named middleware are applied before the middleware of OnConnect that the
+// controller declares, so that they are kept even if the controller implements MiddlewareMapper
+type UpgradeMiddlewareMapper interface {
+	UpgradeMiddleware() []string
+}
+
+// Middleware is the middleware instance that serves the request, e.g. jwt middleware
+type Middleware interface {
+	Serve(ctx context.Context)
+}
+
+// Documenter is implemented by the controller that overrides the summary and description of its operations in the
+// OpenAPI document, the key is the method name and the value is in the form of struct tag,
+// e.g. "GetById": `summary:"Get user" description:"Get user by id"`.
+// The tag and description of the controller are given by the tags of the embedded controller,
+// e.g. web.Controller `tag:"user" description:"User management"`
+type Documenter interface {
+	Documentation() map[string]string
+}
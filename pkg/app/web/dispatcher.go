@@ -17,13 +17,18 @@ package web
 import (
 	"fmt"
 	"github.com/fatih/camelcase"
+	"github.com/hidevopsio/hiboot/pkg/app/web/openapi"
+	"github.com/hidevopsio/hiboot/pkg/factory"
 	"github.com/hidevopsio/hiboot/pkg/inject"
+	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/hidevopsio/hiboot/pkg/system"
 	"github.com/hidevopsio/hiboot/pkg/utils/str"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/context"
 	"net/http"
+	"path"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -40,6 +45,21 @@ var httpMethods = []string{
 }
 
 type dispatcher struct {
+	// wrapResponse wraps the return values other than string, error and model.Response in model.BaseResponse
+	wrapResponse bool
+	// factory is the container that the named middleware of controllers are resolved from
+	factory factory.InstantiateFactory
+	// webSocket is the properties of the connections of websocket controllers
+	webSocket system.WebSocket
+	// versioning is the properties of api versioning, versionedRoutes are the routes shared by the versions of
+	// controllers that are routed by the version in header or media type
+	versioning      system.Versioning
+	versionedRoutes map[string]*versionedRoute
+	// routes and tags are documented in the OpenAPI document
+	routes []route
+	tags   []openapi.Tag
+	// respondOptions responds to the OPTIONS requests of the paths that do not handle OPTIONS
+	respondOptions bool
 }
 
 func (d *dispatcher) register(app *iris.Application, controllers []interface{}) (err error) {
@@ -50,7 +70,6 @@ func (d *dispatcher) register(app *iris.Application, controllers []interface{})
 		//log.Debug("fieldType: ", fieldType)
 		ift := fieldType.Elem()
 		fieldName := ift.Name()
-		pkgPath := ift.PkgPath()
 		//log.Debug("fieldName: ", fieldName)
 
 		controller := field.Interface()
@@ -84,67 +103,393 @@ func (d *dispatcher) register(app *iris.Application, controllers []interface{})
 			contextMapping = pathSep + controllerName
 		}
 
-		numOfMethod := field.NumMethod()
-		//log.Debug("methods: ", numOfMethod)
+		// the websocket controller serves the upgrade request on its context mapping
+		if _, ok := controller.(hubHolder); ok {
+			if err := d.registerWebSocket(app, controller, fieldName, contextMapping); err != nil {
+				return err
+			}
+			continue
+		}
 
-		beforeMethod, ok := fieldType.MethodByName(beforeMethod)
-		var party iris.Party
-		if ok {
-			//log.Debug("contextPath: ", contextMapping)
-			//log.Debug("beforeMethod.Name: ", beforeMethod.Name)
-			hdl := new(handler)
-			hdl.parse(beforeMethod, controller, "")
-			party = app.Party(contextMapping, func(ctx context.Context) {
-				hdl.call(ctx.(*Context))
-			})
-		} else {
-			party = app.Party(contextMapping)
+		version := versionOf(ift)
+		for i, cm := range d.versionedContextMappings(contextMapping, version) {
+			// the operation ids of versioned routes are suffixed by the version, so that they are unique when
+			// the default version is also served without the version prefix
+			operationSuffix := ""
+			if version != nil && i == 0 {
+				operationSuffix = "_" + version.name
+			}
+			err = d.registerController(app, controller, fieldType, cm, controllerName, version, operationSuffix)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// registerController registers the routes of the methods of controller on the context mapping, the operation ids of
+// the routes in the OpenAPI document are suffixed by operationSuffix
+func (d *dispatcher) registerController(app *iris.Application, controller interface{}, fieldType reflect.Type,
+	contextMapping, controllerName string, version *apiVersion, operationSuffix string) error {
+	fieldName := fieldType.Elem().Name()
+	pkgPath := fieldType.Elem().PkgPath()
+
+	// the controller that has Ctx field is copied per request, unless it contains a lock by value, then it is kept
+	// as a singleton whose Ctx is shared by the concurrent requests
+	if ctxFieldIndex(controller) != nil {
+		if lock := lockField(fieldType.Elem()); lock != "" {
+			log.Warnf("controller %v contains the lock %v by value, so its Ctx is shared by the concurrent requests, "+
+				"keep the lock by pointer to have the controller copied per request", fieldName, lock)
+		}
+	}
+
+	numOfMethod := fieldType.NumMethod()
+	//log.Debug("methods: ", numOfMethod)
+
+	// the controllers of different versions share the party of the same path if they are routed by version,
+	// so that their Before and After methods are added into the handlers of their own routes
+	routedByVersion := d.routedByVersion(version)
+	var before, after context.Handlers
+	beforeMethod, ok := fieldType.MethodByName(beforeMethod)
+	if ok {
+		//log.Debug("contextPath: ", contextMapping)
+		//log.Debug("beforeMethod.Name: ", beforeMethod.Name)
+		hdl := new(handler)
+		hdl.parse(beforeMethod, controller, "")
+		before = append(before, func(ctx context.Context) {
+			hdl.call(ctx.(*Context))
+		})
+	}
+	party := app.Party(contextMapping)
+	if !routedByVersion {
+		party = app.Party(contextMapping, before...)
+		before = nil
+	}
+
+	afterMethod, ok := fieldType.MethodByName(afterMethod)
+	if ok {
+		hdl := new(handler)
+		hdl.parse(afterMethod, controller, "")
+		after = append(after, func(ctx context.Context) {
+			hdl.call(ctx.(*Context))
+		})
+	}
+	if !routedByVersion {
+		party.Done(after...)
+		after = nil
+	}
+
+	// the explicit request mappings take precedence over the naming convention
+	mappings, err := parseRequestMappings(controller)
+	if err != nil {
+		return err
+	}
+
+	middleware, err := d.resolveMiddleware(controller)
+	if err != nil {
+		return err
+	}
+
+	tag := d.documentController(fieldType.Elem(), controllerName)
+	var docs map[string]string
+	if documenter, ok := controller.(Documenter); ok {
+		docs = documenter.Documentation()
+	}
+
+	// allowed are the http methods of the paths, which are responded to the OPTIONS requests
+	allowed := make(map[string][]string)
+	var methodNames []string
+	for mi := 0; mi < numOfMethod; mi++ {
+		method := fieldType.Method(mi)
+		methodName := method.Name
+		//log.Debug("method: ", methodName)
+
+		ctxMap := camelcase.Split(methodName)
+		httpMethod := strings.ToUpper(ctxMap[0])
+		mapping, isMapped := mappings[methodName]
+		if isMapped {
+			delete(mappings, methodName)
+			if mapping.method != "" {
+				httpMethod = mapping.method
+			}
 		}
 
-		afterMethod, ok := fieldType.MethodByName(afterMethod)
-		if ok {
+		// apiContextMapping should add arguments
+		//log.Debug("contextMapping: ", apiContextMapping)
+		// check if it's valid http method
+		if str.InSlice(httpMethod, httpMethods) {
+			var apiContextMapping string
+			if isMapped {
+				apiContextMapping = mapping.path
+			} else if len(ctxMap) > 2 && ctxMap[1] == "By" {
+				for _, pathParam := range ctxMap[2:] {
+					lpp := strings.ToLower(pathParam)
+					apiContextMapping = apiContextMapping + pathSep + lpp + pathSep + "{" + lpp + "}"
+				}
+			} else {
+				apiContextMapping = strings.Replace(methodName, ctxMap[0], "", 1)
+				apiContextMapping = pathSep + str.LowerFirst(apiContextMapping)
+			}
+
+			// parse all necessary requests and responses
+			// create new method parser here
 			hdl := new(handler)
-			hdl.parse(afterMethod, controller, "")
-			party.Done(func(ctx context.Context) {
+			hdl.parse(method, controller, contextMapping+apiContextMapping, mapping.params...)
+			hdl.wrapResponse = d.wrapResponse
+			if hdl.lenOfParams < len(mapping.params) {
+				return &system.ErrInvalidRequestMapping{Name: fieldName + "." + methodName, Mapping: mapping.raw}
+			}
+			if i := hdl.unboundArgument(); i != 0 {
+				return &system.ErrUnboundArgument{Name: fieldName + "." + methodName, Index: i}
+			}
+
+			handlers := append(before, middlewareOf(middleware, methodName)...)
+			handlers = append(handlers, func(ctx context.Context) {
 				hdl.call(ctx.(*Context))
+				ctx.Next()
 			})
+			handlers = append(handlers, after...)
+			d.handle(party, httpMethod, contextMapping, apiContextMapping, version, handlers,
+				fmt.Sprintf("%s/%s.%s", pkgPath, fieldName, methodName))
+			allowed[apiContextMapping] = append(allowed[apiContextMapping], httpMethod)
+			methodNames = append(methodNames, methodName)
+
+			d.routes = append(d.routes, route{
+				method:      httpMethod,
+				path:        cleanPath(contextMapping + apiContextMapping),
+				tag:         tag,
+				operationID: fieldName + "." + methodName + operationSuffix,
+				doc:         reflect.StructTag(docs[methodName]),
+				hdl:         hdl,
+			})
+		} else if isMapped {
+			return &system.ErrInvalidRequestMapping{Name: fieldName + "." + methodName, Mapping: mapping.raw}
+		}
+	}
+	for methodName, mapping := range mappings {
+		return &system.ErrInvalidRequestMapping{Name: fieldName + "." + methodName, Mapping: mapping.raw}
+	}
+	if err := checkMiddleware(middleware, fieldName, methodNames); err != nil {
+		return err
+	}
+
+	// the paths without OPTIONS method are responded with the allowed methods, so that the middleware
+	// such as cors can handle the preflight requests
+	for p, methods := range allowed {
+		if d.respondOptions && !str.InSlice(http.MethodOptions, methods) {
+			d.handle(party, http.MethodOptions, contextMapping, p, version,
+				context.Handlers{optionsHandler(methods)}, "")
 		}
+	}
+	return nil
+}
 
-		for mi := 0; mi < numOfMethod; mi++ {
-			method := fieldType.Method(mi)
-			methodName := method.Name
-			//log.Debug("method: ", methodName)
-
-			ctxMap := camelcase.Split(methodName)
-			httpMethod := strings.ToUpper(ctxMap[0])
-
-			// apiContextMapping should add arguments
-			//log.Debug("contextMapping: ", apiContextMapping)
-			// check if it's valid http method
-			if str.InSlice(httpMethod, httpMethods) {
-				var apiContextMapping string
-				if len(ctxMap) > 2 && ctxMap[1] == "By" {
-					for _, pathParam := range ctxMap[2:] {
-						lpp := strings.ToLower(pathParam)
-						apiContextMapping = apiContextMapping + pathSep + lpp + pathSep + "{" + lpp + "}"
-					}
-				} else {
-					apiContextMapping = strings.Replace(methodName, ctxMap[0], "", 1)
-					apiContextMapping = pathSep + str.LowerFirst(apiContextMapping)
+// registerWebSocket registers the handler of websocket controller on GET of the context mapping, the upgrade middleware
+// such as jwt, then the middleware of OnConnect are applied to the upgrade request
+func (d *dispatcher) registerWebSocket(app *iris.Application, controller interface{}, name, contextMapping string) error {
+	wsh, err := newWebSocketHandler(controller, name, d.webSocket)
+	if err != nil {
+		return err
+	}
+	middleware, err := d.resolveMiddleware(controller)
+	if err != nil {
+		return err
+	}
+	if err := checkMiddleware(middleware, name, []string{onConnectMethod}); err != nil {
+		return err
+	}
+	var handlers []context.Handler
+	if mapper, ok := controller.(UpgradeMiddlewareMapper); ok {
+		for _, mwName := range mapper.UpgradeMiddleware() {
+			hdl, err := d.resolveHandler(mwName)
+			if err != nil {
+				return err
+			}
+			handlers = append(handlers, hdl)
+		}
+	}
+	handlers = append(handlers, middlewareOf(middleware, onConnectMethod)...)
+	handlers = append(handlers, func(ctx context.Context) {
+		c := ctx.(*Context)
+		wsh.serve(c.ResponseWriter(), c.Request(), c)
+	})
+	r := app.Handle(http.MethodGet, contextMapping, handlers...)
+	r.MainHandlerName = name + "." + onConnectMethod
+	return nil
+}
+
+// optionsHandler responds the OPTIONS request with the allowed methods in Allow header
+func optionsHandler(methods []string) context.Handler {
+	allow := allowHeader(methods)
+	return func(ctx context.Context) {
+		ctx.Header("Allow", allow)
+		ctx.StatusCode(http.StatusNoContent)
+	}
+}
+
+// allowHeader returns the sorted methods including OPTIONS in comma separated string
+func allowHeader(methods []string) string {
+	allow := []string{http.MethodOptions}
+	for _, m := range methods {
+		if !str.InSlice(m, allow) {
+			allow = append(allow, m)
+		}
+	}
+	sort.Strings(allow)
+	return strings.Join(allow, ", ")
+}
+
+// documentController returns the tag of the controller type in OpenAPI document, which is given by the tag of
+// the embedded controller or the controller name
+func (d *dispatcher) documentController(typ reflect.Type, controllerName string) (tag string) {
+	tag = controllerName
+	var description string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+		if name, ok := f.Tag.Lookup(tagControllerName); ok {
+			tag = name
+		}
+		if desc, ok := f.Tag.Lookup(tagDescription); ok {
+			description = desc
+		}
+	}
+	if tag == "" {
+		return
+	}
+	for _, t := range d.tags {
+		if t.Name == tag {
+			return
+		}
+	}
+	d.tags = append(d.tags, openapi.Tag{Name: tag, Description: description})
+	return
+}
+
+// cleanPath removes the duplicated and trailing slashes of the path
+func cleanPath(p string) string {
+	p = path.Clean(p)
+	if p == "." {
+		return pathSep
+	}
+	return p
+}
+
+type requestMapping struct {
+	method string
+	path   string
+	// params are the names of the primitive arguments that are bound from query, header or cookie
+	params []string
+	raw    string
+}
+
+// parseRequestMappings parse the request mappings in the form of "METHOD /path?param&param" or "/path" of
+// the controller that implements RequestMapper
+func parseRequestMappings(controller interface{}) (mappings map[string]requestMapping, err error) {
+	mappings = make(map[string]requestMapping)
+	mapper, ok := controller.(RequestMapper)
+	if !ok {
+		return
+	}
+	for methodName, raw := range mapper.RequestMapping() {
+		mapping := requestMapping{raw: raw}
+		fields := strings.Fields(raw)
+		switch len(fields) {
+		case 1:
+			mapping.path = fields[0]
+		case 2:
+			mapping.method, mapping.path = strings.ToUpper(fields[0]), fields[1]
+		}
+		if n := strings.Index(mapping.path, "?"); n >= 0 {
+			for _, param := range strings.Split(mapping.path[n+1:], "&") {
+				if param == "" {
+					return nil, &system.ErrInvalidRequestMapping{Name: methodName, Mapping: raw}
 				}
+				mapping.params = append(mapping.params, param)
+			}
+			mapping.path = mapping.path[:n]
+		}
+		if mapping.path == "" || !strings.HasPrefix(mapping.path, pathSep) ||
+			(mapping.method != "" && !str.InSlice(mapping.method, httpMethods)) {
+			return nil, &system.ErrInvalidRequestMapping{Name: methodName, Mapping: raw}
+		}
+		mappings[methodName] = mapping
+	}
+	return
+}
+
+// resolveMiddleware resolves the named middleware of the controller that implements MiddlewareMapper from the container
+func (d *dispatcher) resolveMiddleware(controller interface{}) (middleware map[string][]context.Handler, err error) {
+	middleware = make(map[string][]context.Handler)
+	mapper, ok := controller.(MiddlewareMapper)
+	if !ok {
+		return
+	}
+	for key, names := range mapper.Middleware() {
+		for _, name := range names {
+			hdl, e := d.resolveHandler(name)
+			if e != nil {
+				return nil, e
+			}
+			middleware[key] = append(middleware[key], hdl)
+		}
+	}
+	return
+}
 
-				// parse all necessary requests and responses
-				// create new method parser here
-				hdl := new(handler)
-				hdl.parse(method, controller, contextMapping+apiContextMapping)
+// resolveHandler resolves the middleware instance of name from the container, which is context.Handler or
+// implements Middleware
+func (d *dispatcher) resolveHandler(name string) (hdl context.Handler, err error) {
+	var inst interface{}
+	if d.factory != nil {
+		inst = d.factory.GetInstance(name)
+	}
+	switch m := inst.(type) {
+	case context.Handler:
+		hdl = m
+	case func(context.Context):
+		hdl = m
+	case Middleware:
+		hdl = m.Serve
+	default:
+		err = &system.ErrInvalidMiddleware{Name: name}
+	}
+	return
+}
 
-				route := party.Handle(httpMethod, apiContextMapping, func(ctx context.Context) {
-					hdl.call(ctx.(*Context))
-					ctx.Next()
-				})
-				route.MainHandlerName = fmt.Sprintf("%s/%s.%s", pkgPath, fieldName, methodName)
+// checkMiddleware check if each key of middleware is "*", or it matches any of the methods that serve requests
+func checkMiddleware(middleware map[string][]context.Handler, name string, methodNames []string) error {
+	for key := range middleware {
+		matched := key == "*"
+		for _, methodName := range methodNames {
+			if matched {
+				break
 			}
+			matched = methodName == key ||
+				(strings.HasSuffix(key, "*") && strings.HasPrefix(methodName, strings.TrimSuffix(key, "*")))
+		}
+		if !matched {
+			return &system.ErrInvalidMiddlewareMapping{Name: name, Key: key}
 		}
 	}
 	return nil
 }
+
+// middlewareOf returns the middleware of the method in the order of "*", the matched prefixes and the method name
+func middlewareOf(middleware map[string][]context.Handler, methodName string) (handlers []context.Handler) {
+	handlers = append(handlers, middleware["*"]...)
+	var prefixes []string
+	for key := range middleware {
+		if key != "*" && strings.HasSuffix(key, "*") && strings.HasPrefix(methodName, strings.TrimSuffix(key, "*")) {
+			prefixes = append(prefixes, key)
+		}
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		handlers = append(handlers, middleware[prefix]...)
+	}
+	return append(handlers, middleware[methodName]...)
+}
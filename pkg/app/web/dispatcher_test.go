@@ -0,0 +1,170 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/factory/instantiate"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/hidevopsio/hiboot/pkg/utils/cmap"
+	"github.com/kataras/iris/context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type mappedController struct {
+	Controller
+	mappings map[string]string
+}
+
+func (c *mappedController) RequestMapping() map[string]string {
+	return c.mappings
+}
+
+func TestParseRequestMappings(t *testing.T) {
+	t.Run("should parse request mappings", func(t *testing.T) {
+		mappings, err := parseRequestMappings(&mappedController{mappings: map[string]string{
+			"ListOrders": "get /{id}/orders",
+			"GetOrder":   "/{id}/orders/{orderId}",
+		}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "GET", mappings["ListOrders"].method)
+		assert.Equal(t, "/{id}/orders", mappings["ListOrders"].path)
+		assert.Equal(t, "", mappings["GetOrder"].method)
+		assert.Equal(t, "/{id}/orders/{orderId}", mappings["GetOrder"].path)
+	})
+
+	t.Run("should parse the names of params in query", func(t *testing.T) {
+		mappings, err := parseRequestMappings(&mappedController{mappings: map[string]string{
+			"ListOrders": "GET /{id}/orders?status&page",
+		}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "/{id}/orders", mappings["ListOrders"].path)
+		assert.Equal(t, []string{"status", "page"}, mappings["ListOrders"].params)
+	})
+
+	t.Run("should return empty mappings if controller is not a RequestMapper", func(t *testing.T) {
+		mappings, err := parseRequestMappings(new(FooController))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(mappings))
+	})
+
+	for _, mapping := range []string{"", "FETCH /orders", "GET orders", "GET /orders extra", "GET /orders?", "GET /orders?a&&b"} {
+		t.Run("should report invalid request mapping "+mapping, func(t *testing.T) {
+			_, err := parseRequestMappings(&mappedController{mappings: map[string]string{"ListOrders": mapping}})
+			_, ok := err.(*system.ErrInvalidRequestMapping)
+			assert.Equal(t, true, ok)
+		})
+	}
+}
+
+type middlewareController struct {
+	Controller
+	middleware map[string][]string
+}
+
+func (c *middlewareController) Middleware() map[string][]string {
+	return c.middleware
+}
+
+type tracer struct {
+	name  string
+	trace *[]string
+}
+
+func (t *tracer) Serve(ctx context.Context) {
+	*t.trace = append(*t.trace, t.name)
+}
+
+func TestResolveMiddleware(t *testing.T) {
+	var trace []string
+	f := new(instantiate.InstantiateFactory)
+	f.Initialize(cmap.New())
+	f.SetInstance("logger", context.Handler(func(ctx context.Context) {
+		trace = append(trace, "logger")
+	}))
+	f.SetInstance("auth", &tracer{name: "auth", trace: &trace})
+	f.SetInstance("audit", &tracer{name: "audit", trace: &trace})
+	d := &dispatcher{factory: f}
+
+	t.Run("should apply middleware in order", func(t *testing.T) {
+		middleware, err := d.resolveMiddleware(&middlewareController{middleware: map[string][]string{
+			"*":          {"logger"},
+			"Post*":      {"auth"},
+			"PostOrder":  {"audit"},
+			"DeleteByID": {"auth", "audit"},
+		}})
+		assert.Equal(t, nil, err)
+
+		testData := []struct {
+			method   string
+			expected []string
+		}{
+			{"GetOrder", []string{"logger"}},
+			{"PostOrder", []string{"logger", "auth", "audit"}},
+			{"PostItem", []string{"logger", "auth"}},
+			{"DeleteByID", []string{"logger", "auth", "audit"}},
+		}
+		for _, d := range testData {
+			trace = nil
+			for _, hdl := range middlewareOf(middleware, d.method) {
+				hdl(nil)
+			}
+			assert.Equal(t, d.expected, trace)
+		}
+	})
+
+	t.Run("should report middleware that is not found", func(t *testing.T) {
+		_, err := d.resolveMiddleware(&middlewareController{middleware: map[string][]string{"*": {"unknown"}}})
+		assert.Equal(t, &system.ErrInvalidMiddleware{Name: "unknown"}, err)
+	})
+
+	t.Run("should return empty middleware if controller is not a MiddlewareMapper", func(t *testing.T) {
+		middleware, err := d.resolveMiddleware(new(FooController))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(middlewareOf(middleware, "Get")))
+	})
+}
+
+func TestCheckMiddleware(t *testing.T) {
+	middleware := func(keys ...string) map[string][]context.Handler {
+		m := make(map[string][]context.Handler)
+		for _, key := range keys {
+			m[key] = nil
+		}
+		return m
+	}
+	methodNames := []string{"GetOrder", "PostOrder", "DeleteByID"}
+
+	t.Run("should accept the keys that match the methods", func(t *testing.T) {
+		assert.Equal(t, nil, checkMiddleware(middleware("*", "Post*", "DeleteByID"), "orderController", methodNames))
+	})
+
+	for _, key := range []string{"PutOrder", "Put*", "GetOrders", "getOrder"} {
+		t.Run("should report the key that matches no method "+key, func(t *testing.T) {
+			err := checkMiddleware(middleware("*", key), "orderController", methodNames)
+			assert.Equal(t, &system.ErrInvalidMiddlewareMapping{Name: "orderController", Key: key}, err)
+		})
+	}
+}
+
+func TestAllowHeader(t *testing.T) {
+	t.Run("should add OPTIONS and sort the methods", func(t *testing.T) {
+		assert.Equal(t, "DELETE, GET, OPTIONS, POST", allowHeader([]string{"POST", "GET", "DELETE"}))
+	})
+
+	t.Run("should remove the duplicated methods", func(t *testing.T) {
+		assert.Equal(t, "GET, OPTIONS", allowHeader([]string{"GET", "OPTIONS", "GET"}))
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// errorCodeInternalServerError is the i18n key of the message of internal server errors, which does not reveal
+// the original error
+const errorCodeInternalServerError = "internal_server_error"
+
+// ErrorMapper maps the error to http status code, error code and message, the message is translated by i18n,
+// it returns ok false if the error is not handled by it
+type ErrorMapper func(err error) (status int, code string, message string, ok bool)
+
+// ErrorMapping is implemented by the component that maps errors like ErrorMapper
+type ErrorMapping interface {
+	MapError(err error) (status int, code string, message string, ok bool)
+}
+
+var (
+	errorMappers   []*ErrorMapper
+	errorMappersMu sync.RWMutex
+)
+
+// AddErrorMapper add the global error mapper, which is shared by all applications in the process, the components
+// of the application that are ErrorMapper or implement ErrorMapping take precedence over it, the error mapper added
+// later takes precedence. The errors that are not handled by any error mapper are mapped by system.StatusCoder and
+// system.ErrorCoder, or http.StatusInternalServerError, the returned remove func removes the error mapper
+func AddErrorMapper(mapper ErrorMapper) (remove func()) {
+	errorMappersMu.Lock()
+	defer errorMappersMu.Unlock()
+	// the mapper is kept by pointer, as funcs are not comparable
+	m := &mapper
+	errorMappers = append(errorMappers, m)
+	return func() {
+		errorMappersMu.Lock()
+		defer errorMappersMu.Unlock()
+		for i := range errorMappers {
+			if errorMappers[i] == m {
+				errorMappers = append(errorMappers[:i:i], errorMappers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// errorMappersOf returns the error mappers of the components in the container, which are ErrorMapper or implement
+// ErrorMapping, in the order of their names
+func errorMappersOf(items map[string]interface{}) (mappers []ErrorMapper) {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch m := items[name].(type) {
+		case ErrorMapper:
+			mappers = append(mappers, m)
+		case ErrorMapping:
+			mappers = append(mappers, m.MapError)
+		}
+	}
+	return
+}
+
+// mapError maps err to http status code, error code and message by the error mappers of the application, then the
+// global ones, the message of the internal server error that is not handled by error mappers is generic, so that
+// the internals are not revealed
+func mapError(mappers []ErrorMapper, err error) (status int, code string, message string) {
+	for _, mapper := range mappers {
+		if status, code, message, ok := mapper(err); ok {
+			return status, code, message
+		}
+	}
+
+	errorMappersMu.RLock()
+	defer errorMappersMu.RUnlock()
+	for i := len(errorMappers) - 1; i >= 0; i-- {
+		if status, code, message, ok := (*errorMappers[i])(err); ok {
+			return status, code, message
+		}
+	}
+
+	status = http.StatusInternalServerError
+	if sc, ok := err.(system.StatusCoder); ok {
+		status = sc.StatusCode()
+	}
+	if ec, ok := err.(system.ErrorCoder); ok {
+		code = ec.ErrorCode()
+	}
+	message = err.Error()
+	if status >= http.StatusInternalServerError {
+		message = errorCodeInternalServerError
+	}
+	return
+}
+
+// mapError maps err to http status code, error code and message, the internal server error is logged
+func (ctx *Context) mapError(err error) (status int, code string, message string) {
+	status, code, message = mapError(ctx.errorMappers, err)
+	if status >= http.StatusInternalServerError {
+		ctx.Log().Error(err)
+	}
+	return
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var errTeapot = errors.New("i'm a teapot")
+
+func TestMapError(t *testing.T) {
+	t.Run("should map unknown error to internal server error", func(t *testing.T) {
+		status, code, message := mapError(nil, errors.New("oops"))
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, "", code)
+		assert.Equal(t, errorCodeInternalServerError, message)
+	})
+
+	t.Run("should map typed errors", func(t *testing.T) {
+		testData := []struct {
+			err    error
+			status int
+			code   string
+		}{
+			{&system.ErrNotFound{}, http.StatusNotFound, "not_found"},
+			{&system.ErrBadRequest{Message: "bad"}, http.StatusBadRequest, "bad_request"},
+			{&system.ErrUnauthorized{}, http.StatusUnauthorized, "unauthorized"},
+			{&system.ErrForbidden{}, http.StatusForbidden, "forbidden"},
+			{&system.ErrConflict{Name: "user"}, http.StatusConflict, "conflict"},
+			{&system.ErrInvalidParameter{Name: "id"}, http.StatusBadRequest, "invalid_parameter"},
+		}
+		for _, d := range testData {
+			status, code, message := mapError(nil, d.err)
+			assert.Equal(t, d.status, status)
+			assert.Equal(t, d.code, code)
+			assert.Equal(t, d.err.Error(), message)
+		}
+	})
+
+	t.Run("should map error by error mapper", func(t *testing.T) {
+		remove := AddErrorMapper(func(err error) (status int, code string, message string, ok bool) {
+			if err == errTeapot {
+				return http.StatusTeapot, "teapot", "short and stout", true
+			}
+			return
+		})
+		status, code, message := mapError(nil, errTeapot)
+		assert.Equal(t, http.StatusTeapot, status)
+		assert.Equal(t, "teapot", code)
+		assert.Equal(t, "short and stout", message)
+
+		status, _, _ = mapError(nil, &system.ErrNotFound{})
+		assert.Equal(t, http.StatusNotFound, status)
+
+		remove()
+		status, _, _ = mapError(nil, errTeapot)
+		assert.Equal(t, http.StatusInternalServerError, status)
+	})
+
+	t.Run("should remove the error mapper that is added by the same func", func(t *testing.T) {
+		newMapper := func(status int) ErrorMapper {
+			return func(err error) (int, string, string, bool) {
+				return status, "", "", true
+			}
+		}
+		removeFirst := AddErrorMapper(newMapper(http.StatusTeapot))
+		defer AddErrorMapper(newMapper(http.StatusConflict))()
+		removeFirst()
+		status, _, _ := mapError(nil, errTeapot)
+		assert.Equal(t, http.StatusConflict, status)
+	})
+}
+
+type teapotErrorMapping struct{}
+
+func (m *teapotErrorMapping) MapError(err error) (status int, code string, message string, ok bool) {
+	if err == errTeapot {
+		return http.StatusTeapot, "teapot", "short and stout", true
+	}
+	return
+}
+
+func TestErrorMappersOf(t *testing.T) {
+	mappers := errorMappersOf(map[string]interface{}{
+		"teapotErrorMapping": new(teapotErrorMapping),
+		"conflictErrorMapper": ErrorMapper(func(err error) (int, string, string, bool) {
+			return http.StatusConflict, "conflict", err.Error(), err == errTeapot
+		}),
+		"foo": "bar",
+	})
+
+	t.Run("should collect the error mappers in the order of names", func(t *testing.T) {
+		assert.Equal(t, 2, len(mappers))
+		status, _, _ := mapError(mappers, errTeapot)
+		assert.Equal(t, http.StatusConflict, status)
+		status, _, _ = mapError(mappers[1:], errTeapot)
+		assert.Equal(t, http.StatusTeapot, status)
+	})
+
+	t.Run("should take precedence over the global error mappers", func(t *testing.T) {
+		defer AddErrorMapper(func(err error) (int, string, string, bool) {
+			return http.StatusGone, "", "", true
+		})()
+		status, _, _ := mapError(mappers[1:], errTeapot)
+		assert.Equal(t, http.StatusTeapot, status)
+		status, _, _ = mapError(mappers[1:], &system.ErrNotFound{})
+		assert.Equal(t, http.StatusGone, status)
+	})
+}
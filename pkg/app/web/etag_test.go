@@ -0,0 +1,79 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	etag := weakETag([]byte(`{"name":"hiboot"}`))
+
+	t.Run("should generate the same weak etag of the same body", func(t *testing.T) {
+		assert.Equal(t, etag, weakETag([]byte(`{"name":"hiboot"}`)))
+		assert.NotEqual(t, etag, weakETag([]byte(`{"name":"hiboot2"}`)))
+		assert.Equal(t, `W/"`, etag[:3])
+	})
+
+	testData := []struct {
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"", false},
+		{"*", true},
+		{etag, true},
+		{etag[2:], true},
+		{`W/"0-0", ` + etag, true},
+		{`W/"0-0"`, false},
+	}
+	for _, data := range testData {
+		t.Run("should match If-None-Match "+data.ifNoneMatch, func(t *testing.T) {
+			assert.Equal(t, data.expected, etagMatches(data.ifNoneMatch, etag))
+		})
+	}
+}
+
+func (c *bookController) GetById(id int) book {
+	return book{Title: "hiboot"}
+}
+
+func TestConditionalRequest(t *testing.T) {
+	a := new(testApplication)
+	err := a.initialize(new(bookController))
+	assert.Equal(t, nil, err)
+	a.SystemConfig().Server.ETag = true
+	a.expect, err = a.RunTestServer(t)
+	assert.Equal(t, nil, err)
+
+	etag := a.Get("/book/1").
+		Expect().Status(http.StatusOK).
+		Header("ETag").NotEmpty().Raw()
+
+	t.Run("should respond not modified if the etag matches", func(t *testing.T) {
+		a.Get("/book/1").
+			WithHeader("If-None-Match", etag).
+			Expect().Status(http.StatusNotModified).
+			Body().Empty()
+	})
+
+	t.Run("should respond the changed resource", func(t *testing.T) {
+		a.Get("/book").
+			WithHeader("If-None-Match", etag).
+			Expect().Status(http.StatusOK).
+			Header("ETag").NotEqual(etag)
+	})
+}
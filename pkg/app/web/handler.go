@@ -15,17 +15,20 @@
 package web
 
 import (
-	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
 	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
 	"github.com/hidevopsio/hiboot/pkg/utils/replacer"
-	"github.com/hidevopsio/hiboot/pkg/utils/str"
 	"net/http"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 )
 
+// lockerType is the type of sync.Locker, the controller that contains a lock by value can not be copied per request
+var lockerType = reflect.TypeOf((*sync.Locker)(nil)).Elem()
+
 type request struct {
 	typeName string
 	name     string
@@ -36,6 +39,13 @@ type request struct {
 	val      reflect.Value
 	iVal     reflect.Value
 	pathIdx  int
+	// isPathParam is true if the argument is bound from the path param
+	isPathParam bool
+	// paramName is the name of the primitive argument that is bound from query, header or cookie in order,
+	// it is given by the query of request mapping, e.g. "GET /orders?status&page"
+	paramName string
+
+	hasBindingTags bool
 }
 
 type response struct {
@@ -46,7 +56,12 @@ type response struct {
 }
 
 type handler struct {
-	controller      interface{}
+	controller interface{}
+	// ctxIndex is the index of Ctx field of the controller, the controller is copied for each request if it has one
+	ctxIndex []int
+	// singleton is true if the controller contains a lock by value, which can not be copied, so that the ctx is set
+	// on the singleton controller as before
+	singleton       bool
 	method          reflect.Method
 	inputs          []reflect.Value
 	numIn           int
@@ -55,9 +70,14 @@ type handler struct {
 	requests        []request
 	responses       []response
 	lenOfPathParams int
+	// lenOfParams is the number of primitive arguments that are named by params
+	lenOfParams  int
+	wrapResponse bool
 }
 
-func (h *handler) parse(method reflect.Method, object interface{}, path string) {
+// parse parses the arguments and results of the controller method, the path params are bound to the leading
+// primitive arguments in order, then the params name the rest primitive arguments
+func (h *handler) parse(method reflect.Method, object interface{}, path string, params ...string) {
 	//log.Debug("NumIn: ", method.Type.NumIn())
 	h.controller = object
 	h.method = method
@@ -65,6 +85,8 @@ func (h *handler) parse(method reflect.Method, object interface{}, path string)
 	h.numOut = method.Type.NumOut()
 	h.inputs = make([]reflect.Value, h.numIn)
 	h.inputs[0] = reflect.ValueOf(object)
+	h.ctxIndex = ctxFieldIndex(object)
+	h.singleton = h.ctxIndex != nil && lockField(h.inputs[0].Type().Elem()) != ""
 
 	//log.Debugf("method: %v", method.Name)
 
@@ -84,6 +106,9 @@ func (h *handler) parse(method reflect.Method, object interface{}, path string)
 		model.RequestTypeForm,
 		model.RequestTypeParams,
 		model.RequestTypeBody,
+		model.RequestTypeHeader,
+		model.RequestTypeCookie,
+		model.RequestTypeMultipart,
 		model.Context,
 	}
 
@@ -112,14 +137,20 @@ func (h *handler) parse(method reflect.Method, object interface{}, path string)
 		pi := i - 1
 		if pi < lenOfPathParams {
 			h.requests[i].name = pp[pi][1]
+			h.requests[i].isPathParam = true
 			for idx, pv := range pps {
 				if pv == pp[pi][0] {
 					h.requests[i].pathIdx = idx
 					break
 				}
 			}
+		} else if h.requests[i].kind != reflect.Struct && h.lenOfParams < len(params) {
+			h.requests[i].name = params[h.lenOfParams]
+			h.requests[i].paramName = params[h.lenOfParams]
+			h.lenOfParams++
 		}
 		h.requests[i].typeName = iTyp.Name()
+		h.requests[i].hasBindingTags = hasBindingTags(iTyp)
 		if iTyp.Kind() == reflect.Struct {
 			for _, tn := range typeNames {
 				if field, ok := iTyp.FieldByName(tn); ok && field.Anonymous {
@@ -150,48 +181,99 @@ func (h *handler) responseData(ctx *Context, numOut int, results []reflect.Value
 
 	result := results[0]
 	if !result.CanInterface() {
-		log.Warn("response is invalid")
+		ctx.Log().Warn("response is invalid")
 	}
 
+	// the error in last result is mapped by error mappers
+	var respErr error
+	if numOut >= 2 && h.responses[numOut-1].typeName == "error" {
+		if errVal := results[numOut-1]; !errVal.IsNil() {
+			respErr = errVal.Interface().(error)
+		}
+	}
+
+	// the typed nil, e.g. nil *model.BaseResponse of return nil, err, is not a nil interface
 	respVal := result.Interface()
-	if respVal == nil {
+	if respVal == nil || (result.Kind() == reflect.Ptr && result.IsNil()) {
+		if respErr != nil {
+			ctx.ResponseErr(respErr)
+			return
+		}
 		// TODO: add unit test
-		log.Warn("response is nil")
+		ctx.Log().Warn("response is nil")
+		return
+	}
+
+	// the view model is rendered by the template engine
+	if v, ok := viewOf(respVal); ok {
+		if respErr != nil {
+			ctx.ResponseErr(respErr)
+			return
+		}
+		if err := ctx.render(v); err != nil {
+			ctx.ResponseErr(err)
+		}
+		return
+	}
+
+	// the stream is written until it ends or the client disconnects
+	if isStream(h.responses[0].typ) {
+		if respErr != nil {
+			ctx.ResponseErr(respErr)
+			return
+		}
+		if err := writeStream(ctx.ResponseWriter(), ctx.Request().Context().Done(), result); err != nil {
+			ctx.Log().Warn(err)
+		}
 		return
 	}
 
-	switch h.responses[0].typeName {
-	case "string":
+	// the response is detected by its type instead of the name, as any type can be named Response
+	response, isResponse := respVal.(model.Response)
+	switch {
+	case h.responses[0].typeName == "string":
+		if respErr != nil {
+			ctx.ResponseErr(respErr)
+			return
+		}
 		ctx.ResponseString(result.Interface().(string))
-	case "error":
-		respErr := result.Interface().(error)
-		ctx.ResponseError(respErr.Error(), http.StatusInternalServerError)
-	case "Response":
-		response := respVal.(model.Response)
+	case h.responses[0].typeName == "error":
+		ctx.ResponseErr(result.Interface().(error))
+	case isResponse:
 		if numOut >= 2 {
-			var respErr error
-			errVal := results[1]
-			if errVal.IsNil() {
-				respErr = nil
-			} else if errVal.Type().Name() == "error" {
-				respErr = results[1].Interface().(error)
-			}
-
 			if respErr == nil {
 				response.SetCode(http.StatusOK)
 				response.SetMessage(ctx.translate("success"))
 			} else {
+				code, errorCode, message := ctx.mapError(respErr)
+				// the code that is set by controller takes precedence
 				if response.GetCode() == 0 {
-					response.SetCode(http.StatusInternalServerError)
+					response.SetCode(code)
 				}
-				// TODO: output error message directly? how about i18n
-				response.SetMessage(ctx.translate(respErr.Error()))
+				if er, ok := response.(model.ErrorResponse); ok && er.GetErrorCode() == "" {
+					er.SetErrorCode(errorCode)
+				}
+				response.SetMessage(ctx.translate(message))
 
-				// TODO: configurable status code in application.yml
 				ctx.StatusCode(response.GetCode())
 			}
 		}
-		ctx.JSON(response)
+		ctx.Negotiate(response)
+	default:
+		if respErr != nil {
+			ctx.ResponseErr(respErr)
+			return
+		}
+		contentType := negotiate(ctx.GetHeader("Accept"), respVal)
+		// the protobuf message is not wrapped as model.BaseResponse is not a proto message
+		if h.wrapResponse && contentType != contentTypeProtobuf {
+			response := new(model.BaseResponse)
+			response.SetCode(http.StatusOK)
+			response.SetMessage(ctx.translate("success"))
+			response.SetData(respVal)
+			respVal = response
+		}
+		ctx.write(contentType, respVal)
 	}
 }
 
@@ -202,6 +284,9 @@ func (h *handler) call(ctx *Context) {
 	var path string
 	var pvs []string
 
+	// the inputs are created for each call, as the handler is shared by the concurrent requests
+	inputs := make([]reflect.Value, h.numIn)
+
 	if h.lenOfPathParams != 0 {
 		path = ctx.Path()
 		//log.Debugf("path: %v", path)
@@ -210,8 +295,9 @@ func (h *handler) call(ctx *Context) {
 
 	for i := 1; i < h.numIn; i++ {
 		req := h.requests[i]
-		request = req.iVal.Interface()
 		if req.kind == reflect.Struct {
+			// the request is created for each call, so that the values of last request are not kept
+			request = reflect.New(req.iTyp).Interface()
 			switch req.typeName {
 			case model.RequestTypeForm:
 				reqErr = ctx.RequestForm(request)
@@ -219,24 +305,42 @@ func (h *handler) call(ctx *Context) {
 				reqErr = ctx.RequestParams(request)
 			case model.RequestTypeBody:
 				reqErr = ctx.RequestBody(request)
+			case model.RequestTypeHeader:
+				reqErr = ctx.RequestHeader(request)
+			case model.RequestTypeCookie:
+				reqErr = ctx.RequestCookie(request)
+			case model.RequestTypeMultipart:
+				reqErr = ctx.RequestMultipart(request)
 			case model.Context:
 				request = ctx
+			default:
+				if req.hasBindingTags {
+					reqErr = ctx.RequestEx(request, func() error { return nil })
+				}
 			}
 
 			if reqErr != nil {
 				e := reqErr.Error()
-				log.Error(e)
+				ctx.Log().Error(e)
 				return
 			}
 
-			h.inputs[i] = reflect.ValueOf(request)
-			break
-		} else if h.lenOfPathParams != 0 {
-			strVal := pvs[req.pathIdx]
-			val := str.Convert(strVal, req.kind)
-			h.inputs[i] = reflect.ValueOf(val)
+			inputs[i] = reflect.ValueOf(request)
+		} else if req.isPathParam || req.paramName != "" {
+			var values []string
+			if req.isPathParam {
+				values = []string{pvs[req.pathIdx]}
+			} else {
+				values = lookupParam(ctx.Request(), req.paramName)
+			}
+			val := reflect.New(req.typ).Elem()
+			if err := setValue(val, values); err != nil {
+				ctx.ResponseErr(&system.ErrInvalidParameter{Name: req.name, Value: strings.Join(values, ","), Type: req.typ.String()})
+				return
+			}
+			inputs[i] = val
 		} else {
-			log.Warn("input type is not supported!")
+			ctx.Log().Warn("input type is not supported!")
 			return
 		}
 	}
@@ -244,9 +348,82 @@ func (h *handler) call(ctx *Context) {
 	//var respErr error
 	var results []reflect.Value
 	if reqErr == nil {
-		reflector.SetFieldValue(h.controller, "Ctx", ctx)
+		inputs[0] = h.controllerOf(ctx)
 		// call controller method
-		results = h.method.Func.Call(h.inputs)
+		results = h.method.Func.Call(inputs)
 		h.responseData(ctx, h.numOut, results)
 	}
 }
+
+// unboundArgument returns the index of the primitive argument that is neither a path param nor named by params,
+// or 0 if all arguments are bound
+func (h *handler) unboundArgument() int {
+	for i := 1; i < h.numIn; i++ {
+		req := h.requests[i]
+		if req.kind != reflect.Struct && !req.isPathParam && req.paramName == "" {
+			return i
+		}
+	}
+	return 0
+}
+
+// ctxFieldIndex returns the index of the exported field Ctx of type *Context, which is not embedded by pointer,
+// or nil if the controller does not have one
+func ctxFieldIndex(controller interface{}) []int {
+	typ := reflect.TypeOf(controller)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	typ = typ.Elem()
+	f, ok := typ.FieldByName("Ctx")
+	if !ok || f.PkgPath != "" || f.Type != reflect.TypeOf((*Context)(nil)) {
+		return nil
+	}
+	for i := range f.Index[:len(f.Index)-1] {
+		if typ.FieldByIndex(f.Index[:i+1]).Type.Kind() == reflect.Ptr {
+			return nil
+		}
+	}
+	return f.Index
+}
+
+// lockField returns the path of the field of struct typ that is or contains a lock by value, which must not be
+// copied, or empty string if there is none
+func lockField(typ reflect.Type) string {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Type.Kind() != reflect.Struct {
+			continue
+		}
+		if reflect.PtrTo(f.Type).Implements(lockerType) {
+			return f.Name
+		}
+		if name := lockField(f.Type); name != "" {
+			return f.Name + "." + name
+		}
+	}
+	return ""
+}
+
+// controllerOf returns the controller that the method is called on, the controller is a singleton that is shared by
+// the concurrent requests, so that a shallow copy of it is made for each request to hold the ctx in its Ctx field.
+// The copy is kept in ctx, so that Before, the method and After of the controller are called on the same copy.
+// The controller that contains a lock by value is not copied, its Ctx is set on the singleton instead
+func (h *handler) controllerOf(ctx *Context) reflect.Value {
+	controller := h.inputs[0]
+	if h.ctxIndex == nil {
+		return controller
+	}
+	if h.singleton {
+		controller.Elem().FieldByIndex(h.ctxIndex).Set(reflect.ValueOf(ctx))
+		return controller
+	}
+	if ctx.controller.IsValid() && ctx.singleton == controller.Pointer() {
+		return ctx.controller
+	}
+	c := reflect.New(controller.Type().Elem())
+	c.Elem().Set(controller.Elem())
+	c.Elem().FieldByIndex(h.ctxIndex).Set(reflect.ValueOf(ctx))
+	ctx.controller, ctx.singleton = c, controller.Pointer()
+	return c
+}
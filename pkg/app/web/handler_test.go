@@ -17,7 +17,9 @@ package web
 import (
 	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -53,4 +55,167 @@ func TestParse(t *testing.T) {
 		assert.Equal(t, "string", hdl.requests[2].typeName)
 		assert.Equal(t, "int", hdl.requests[3].typeName)
 	})
+
+	t.Run("should name the primitive arguments after path params by params", func(t *testing.T) {
+		method, _ := ctrlVal.Type().MethodByName("PutByIdNameAge")
+		hdl := new(handler)
+		hdl.parse(method, controller, "/foo/{id}", "name", "age")
+		assert.Equal(t, true, hdl.requests[1].isPathParam)
+		assert.Equal(t, "name", hdl.requests[2].paramName)
+		assert.Equal(t, "age", hdl.requests[3].paramName)
+		assert.Equal(t, 2, hdl.lenOfParams)
+		assert.Equal(t, 0, hdl.unboundArgument())
+	})
+
+	t.Run("should report the primitive argument that is not bound", func(t *testing.T) {
+		method, _ := ctrlVal.Type().MethodByName("PutByIdNameAge")
+		hdl := new(handler)
+		hdl.parse(method, controller, "/foo/{id}", "name")
+		assert.Equal(t, 3, hdl.unboundArgument())
+	})
+}
+
+type bookController struct {
+	Controller
+}
+
+func (c *bookController) Get() []book {
+	return []book{{Title: "hiboot"}}
+}
+
+func TestWrapResponse(t *testing.T) {
+	a := new(testApplication)
+	err := a.initialize(new(bookController))
+	assert.Equal(t, nil, err)
+	a.SystemConfig().Server.WrapResponse = true
+	a.expect, err = a.RunTestServer(t)
+	assert.Equal(t, nil, err)
+
+	t.Run("should wrap the slice in base response", func(t *testing.T) {
+		obj := a.Get("/book").Expect().Status(http.StatusOK).JSON().Object()
+		obj.ValueEqual("code", http.StatusOK)
+		obj.Value("data").Array().Element(0).Object().ValueEqual("title", "hiboot")
+	})
+}
+
+type counter struct {
+	sync.Mutex
+	count int
+}
+
+type ctxController struct {
+	Controller
+	counter *counter
+}
+
+func (c *ctxController) Get() *Context {
+	c.counter.Lock()
+	defer c.counter.Unlock()
+	c.counter.count++
+	return c.Ctx
+}
+
+type lockedController struct {
+	Controller
+	counter
+}
+
+func (c *lockedController) Get() *Context {
+	return c.Ctx
+}
+
+type statefulController struct {
+	Controller
+	user string
+}
+
+func (c *statefulController) Before() {
+	c.user = "john"
+}
+
+func (c *statefulController) Get() string {
+	return c.user
+}
+
+func TestLockField(t *testing.T) {
+	assert.Equal(t, "", lockField(reflect.TypeOf(ctxController{})))
+	assert.Equal(t, "Mutex", lockField(reflect.TypeOf(counter{})))
+	assert.Equal(t, "RWMutex", lockField(reflect.TypeOf(struct{ sync.RWMutex }{})))
+	assert.NotEqual(t, "", lockField(reflect.TypeOf(struct{ once sync.Once }{})))
+}
+
+func TestLockedController(t *testing.T) {
+	controller := new(lockedController)
+	method, _ := reflect.TypeOf(controller).MethodByName("Get")
+	hdl := new(handler)
+	hdl.parse(method, controller, "/")
+
+	t.Run("should keep the controller that contains a lock by value as a singleton", func(t *testing.T) {
+		ctx := new(Context)
+		c := hdl.controllerOf(ctx)
+		assert.Equal(t, reflect.ValueOf(controller).Pointer(), c.Pointer())
+		assert.Equal(t, ctx, controller.Ctx)
+	})
+}
+
+func TestSharedController(t *testing.T) {
+	controller := new(statefulController)
+	before, _ := reflect.TypeOf(controller).MethodByName("Before")
+	get, _ := reflect.TypeOf(controller).MethodByName("Get")
+	hb := new(handler)
+	hb.parse(before, controller, "")
+	hg := new(handler)
+	hg.parse(get, controller, "/")
+
+	t.Run("should see the state that is set in Before", func(t *testing.T) {
+		ctx := new(Context)
+		hb.method.Func.Call([]reflect.Value{hb.controllerOf(ctx)})
+		out := hg.method.Func.Call([]reflect.Value{hg.controllerOf(ctx)})
+		assert.Equal(t, "john", out[0].String())
+		assert.Equal(t, "", controller.user)
+	})
+}
+
+func TestControllerOf(t *testing.T) {
+	controller := &ctxController{counter: new(counter)}
+	method, _ := reflect.TypeOf(controller).MethodByName("Get")
+	hdl := new(handler)
+	hdl.parse(method, controller, "/ctx")
+
+	t.Run("should call the method with the ctx of each request concurrently", func(t *testing.T) {
+		const n = 100
+		var wg sync.WaitGroup
+		results := make([]bool, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ctx := new(Context)
+				out := hdl.method.Func.Call([]reflect.Value{hdl.controllerOf(ctx)})
+				results[i] = out[0].Interface().(*Context) == ctx
+			}(i)
+		}
+		wg.Wait()
+		for _, ok := range results {
+			assert.Equal(t, true, ok)
+		}
+		assert.Equal(t, n, controller.counter.count)
+		assert.Equal(t, (*Context)(nil), controller.Ctx)
+	})
+
+	t.Run("should share the copy of controller in the same request", func(t *testing.T) {
+		ctx := new(Context)
+		c := hdl.controllerOf(ctx)
+		assert.Equal(t, c.Pointer(), hdl.controllerOf(ctx).Pointer())
+
+		other := new(handler)
+		other.parse(method, &ctxController{counter: new(counter)}, "/ctx")
+		assert.NotEqual(t, c.Pointer(), other.controllerOf(ctx).Pointer())
+	})
+
+	t.Run("should not copy the controller without Ctx field", func(t *testing.T) {
+		assert.Equal(t, []int(nil), ctxFieldIndex(new(struct{ AnonController })))
+		assert.Equal(t, []int(nil), ctxFieldIndex(new(struct{ *Controller })))
+		assert.Equal(t, []int{0, 2}, ctxFieldIndex(new(FooController)))
+	})
 }
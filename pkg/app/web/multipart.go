@@ -0,0 +1,163 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
+	"github.com/hidevopsio/hiboot/pkg/utils/str"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	tagForm = "form"
+
+	defaultMaxMemory = 32 << 20
+)
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+
+	sizeUnits = []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+)
+
+// multipartLimits is the size limits of multipart request in bytes, 0 means unlimited
+type multipartLimits struct {
+	maxMemory      int64
+	maxRequestSize int64
+	maxFileSize    int64
+}
+
+// parseSize parses the size in bytes, e.g. 1024, 512KB, 32MB or 1GB
+func parseSize(raw string) (size int64, err error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if s == "" {
+		return
+	}
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	size, err = strconv.ParseInt(s, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %v, e.g. 512KB, 32MB or 1GB", raw)
+	}
+	return size * unit, nil
+}
+
+// newMultipartLimits parses the size limits of multipart request
+func newMultipartLimits(conf system.Multipart) (limits *multipartLimits, err error) {
+	limits = new(multipartLimits)
+	if limits.maxMemory, err = parseSize(conf.MaxMemory); err != nil {
+		return
+	}
+	if limits.maxRequestSize, err = parseSize(conf.MaxRequestSize); err != nil {
+		return
+	}
+	limits.maxFileSize, err = parseSize(conf.MaxFileSize)
+	return
+}
+
+// bindMultipart parses the multipart request then binds the form values and files into the fields of data,
+// the parts larger than max memory are streamed to temp files, which are removed by the http server after
+// the request is served
+func bindMultipart(w http.ResponseWriter, r *http.Request, data interface{}, limits *multipartLimits) error {
+	if limits == nil {
+		limits = &multipartLimits{maxMemory: defaultMaxMemory}
+	}
+	if limits.maxRequestSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limits.maxRequestSize)
+	}
+	maxMemory := limits.maxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		// the error of http.MaxBytesReader
+		if strings.Contains(err.Error(), "request body too large") {
+			return &system.ErrRequestTooLarge{Limit: limits.maxRequestSize}
+		}
+		return &system.ErrBadRequest{Message: err.Error()}
+	}
+
+	v := reflector.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	form := r.MultipartForm
+	for _, f := range reflector.DeepFields(v.Type()) {
+		if f.Anonymous || f.PkgPath != "" {
+			continue
+		}
+		name, ok := fieldName(f, tagForm, true)
+		if !ok {
+			continue
+		}
+		field := v.FieldByName(f.Name)
+		if !field.CanSet() {
+			continue
+		}
+
+		if f.Type == fileHeaderType || f.Type == fileHeadersType {
+			files, ok := form.File[name]
+			if !ok && name == f.Name {
+				files, ok = form.File[str.LowerFirst(name)]
+			}
+			if !ok || len(files) == 0 {
+				continue
+			}
+			for _, fh := range files {
+				if limits.maxFileSize > 0 && fh.Size > limits.maxFileSize {
+					return &system.ErrFileTooLarge{Name: fh.Filename, Size: fh.Size, Limit: limits.maxFileSize}
+				}
+			}
+			if f.Type == fileHeaderType {
+				field.Set(reflect.ValueOf(files[0]))
+			} else {
+				field.Set(reflect.ValueOf(files))
+			}
+			continue
+		}
+
+		values, ok := form.Value[name]
+		if !ok && name == f.Name {
+			values, ok = form.Value[str.LowerFirst(name)]
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(field, values); err != nil {
+			return &system.ErrInvalidParameter{Name: name, Value: strings.Join(values, ","), Type: f.Type.String()}
+		}
+	}
+	return nil
+}
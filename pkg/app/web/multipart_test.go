@@ -0,0 +1,162 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type uploadRequest struct {
+	model.RequestMultipart
+	Title       string
+	Tags        []string              `form:"tag"`
+	Avatar      *multipart.FileHeader `form:"avatar"`
+	Attachments []*multipart.FileHeader
+}
+
+func newMultipartRequest(t *testing.T, values map[string][]string, files map[string][]string) *http.Request {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	for name, vs := range values {
+		for _, v := range vs {
+			assert.Equal(t, nil, w.WriteField(name, v))
+		}
+	}
+	for name, contents := range files {
+		for i, content := range contents {
+			fw, err := w.CreateFormFile(name, fmt.Sprintf("%v%v.txt", name, i))
+			assert.Equal(t, nil, err)
+			fw.Write([]byte(content))
+		}
+	}
+	assert.Equal(t, nil, w.Close())
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		size     string
+		expected int64
+	}{
+		{"", 0},
+		{"0", 0},
+		{"1024", 1024},
+		{"512B", 512},
+		{"512KB", 512 << 10},
+		{"32mb", 32 << 20},
+		{"1 GB", 1 << 30},
+	}
+	for _, tc := range testCases {
+		t.Run("should parse "+tc.size, func(t *testing.T) {
+			size, err := parseSize(tc.size)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tc.expected, size)
+		})
+	}
+
+	t.Run("should report invalid size", func(t *testing.T) {
+		_, err := parseSize("32XB")
+		assert.Contains(t, err.Error(), "32XB")
+		_, err = parseSize("-1MB")
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestNewMultipartLimits(t *testing.T) {
+	limits, err := newMultipartLimits(system.Multipart{MaxMemory: "1KB", MaxRequestSize: "1MB", MaxFileSize: "512KB"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &multipartLimits{maxMemory: 1 << 10, maxRequestSize: 1 << 20, maxFileSize: 512 << 10}, limits)
+
+	_, err = newMultipartLimits(system.Multipart{MaxFileSize: "large"})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestBindMultipart(t *testing.T) {
+	values := map[string][]string{"title": {"hiboot"}, "tag": {"go", "web"}}
+	files := map[string][]string{"avatar": {"avatar"}, "attachments": {"foo", "bar"}}
+
+	t.Run("should bind form values and files", func(t *testing.T) {
+		r := newMultipartRequest(t, values, files)
+		req := new(uploadRequest)
+		err := bindMultipart(httptest.NewRecorder(), r, req, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "hiboot", req.Title)
+		assert.Equal(t, []string{"go", "web"}, req.Tags)
+		assert.Equal(t, "avatar0.txt", req.Avatar.Filename)
+		assert.Equal(t, 2, len(req.Attachments))
+
+		f, err := req.Attachments[1].Open()
+		assert.Equal(t, nil, err)
+		defer f.Close()
+		content, _ := ioutil.ReadAll(f)
+		assert.Equal(t, "bar", string(content))
+	})
+
+	t.Run("should stream the files to temp files above max memory", func(t *testing.T) {
+		large := strings.Repeat("x", 4096)
+		r := newMultipartRequest(t, nil, map[string][]string{"avatar": {large}})
+		req := new(uploadRequest)
+		err := bindMultipart(httptest.NewRecorder(), r, req, &multipartLimits{maxMemory: 1024})
+		assert.Equal(t, nil, err)
+		defer r.MultipartForm.RemoveAll()
+		f, err := req.Avatar.Open()
+		assert.Equal(t, nil, err)
+		defer f.Close()
+		content, _ := ioutil.ReadAll(f)
+		assert.Equal(t, large, string(content))
+	})
+
+	t.Run("should reject the file larger than max file size", func(t *testing.T) {
+		r := newMultipartRequest(t, nil, map[string][]string{"avatar": {"avatar"}})
+		err := bindMultipart(httptest.NewRecorder(), r, new(uploadRequest), &multipartLimits{maxFileSize: 4})
+		assert.Equal(t, &system.ErrFileTooLarge{Name: "avatar0.txt", Size: 6, Limit: 4}, err)
+	})
+
+	t.Run("should reject the request larger than max request size", func(t *testing.T) {
+		r := newMultipartRequest(t, values, files)
+		err := bindMultipart(httptest.NewRecorder(), r, new(uploadRequest), &multipartLimits{maxRequestSize: 64})
+		assert.Equal(t, &system.ErrRequestTooLarge{Limit: 64}, err)
+	})
+
+	t.Run("should reject the request that is not multipart", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("title=hiboot"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		err := bindMultipart(httptest.NewRecorder(), r, new(uploadRequest), nil)
+		_, ok := err.(*system.ErrBadRequest)
+		assert.Equal(t, true, ok)
+	})
+
+	t.Run("should report the invalid value", func(t *testing.T) {
+		r := newMultipartRequest(t, map[string][]string{"Count": {"many"}}, nil)
+		req := new(struct {
+			model.RequestMultipart
+			Count int
+		})
+		err := bindMultipart(httptest.NewRecorder(), r, req, nil)
+		assert.Equal(t, &system.ErrInvalidParameter{Name: "Count", Value: "many", Type: "int"}, err)
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/xml"
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/yaml.v2"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentTypeJSON     = "application/json"
+	contentTypeXML      = "application/xml"
+	contentTypeYAML     = "application/x-yaml"
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// wildcards are the media types in Accept header that any content type matches
+var wildcards = map[string]bool{
+	"*/*":           true,
+	"application/*": true,
+}
+
+// mediaTypes maps the media types in Accept header to the content types that the response is encoded in
+var mediaTypes = map[string]string{
+	"application/json":       contentTypeJSON,
+	"application/xml":        contentTypeXML,
+	"text/xml":               contentTypeXML,
+	"application/x-yaml":     contentTypeYAML,
+	"application/yaml":       contentTypeYAML,
+	"text/yaml":              contentTypeYAML,
+	"text/x-yaml":            contentTypeYAML,
+	"application/x-protobuf": contentTypeProtobuf,
+	"application/protobuf":   contentTypeProtobuf,
+}
+
+type acceptedType struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parse the media types of Accept header, which are sorted by quality in descending order
+func parseAccept(accept string) (types []acceptedType) {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		at := acceptedType{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		if at.mediaType == "" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					at.quality = q
+				}
+			}
+		}
+		if at.quality > 0 {
+			types = append(types, at)
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].quality > types[j].quality
+	})
+	return
+}
+
+// negotiate returns the content type that data is encoded in according to the Accept header, the media types are
+// walked in the order of quality, the protobuf is accepted only if data is a proto message, and json is the default
+// content type. The client that accepts any type by a wildcard gets json if its preferred type is the wildcard or a
+// type that is not supported, e.g. the browsers send text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8
+// and get json, while application/xml,*/*;q=0.1 gets xml
+func negotiate(accept string, data interface{}) string {
+	types := parseAccept(accept)
+	anyType := false
+	for _, at := range types {
+		if wildcards[at.mediaType] {
+			anyType = true
+		}
+	}
+	for _, at := range types {
+		if wildcards[at.mediaType] {
+			return contentTypeJSON
+		}
+		contentType, ok := mediaTypes[at.mediaType]
+		if ok && contentType == contentTypeProtobuf {
+			_, ok = data.(proto.Message)
+		}
+		if ok {
+			return contentType
+		}
+		if anyType {
+			return contentTypeJSON
+		}
+	}
+	return contentTypeJSON
+}
+
+// encode encodes data in xml, yaml or protobuf
+func encode(contentType string, data interface{}) ([]byte, error) {
+	switch contentType {
+	case contentTypeXML:
+		return xml.Marshal(data)
+	case contentTypeYAML:
+		return yaml.Marshal(data)
+	case contentTypeProtobuf:
+		return proto.Marshal(data.(proto.Message))
+	}
+	return nil, ErrUnsupportedType
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type greeting struct {
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (m *greeting) Reset()         { *m = greeting{} }
+func (m *greeting) String() string { return proto.CompactTextString(m) }
+func (*greeting) ProtoMessage()    {}
+
+type book struct {
+	Title string `json:"title" xml:"title" yaml:"title"`
+}
+
+func TestNegotiate(t *testing.T) {
+	testData := []struct {
+		name     string
+		accept   string
+		data     interface{}
+		expected string
+	}{
+		{"should be json by default", "", &book{}, contentTypeJSON},
+		{"should be json for any type", "*/*", &book{}, contentTypeJSON},
+		{"should be xml", "text/xml", &book{}, contentTypeXML},
+		{"should be yaml", "application/yaml", &book{}, contentTypeYAML},
+		{"should be the type of highest quality", "application/json;q=0.5, application/xml;q=0.9", &book{}, contentTypeXML},
+		{"should skip the type of zero quality", "application/xml;q=0, text/yaml", &book{}, contentTypeYAML},
+		{"should skip unknown type", "text/csv, application/x-yaml", &book{}, contentTypeYAML},
+		{"should be protobuf for proto message", "application/x-protobuf", &greeting{}, contentTypeProtobuf},
+		{"should not be protobuf for non proto message", "application/x-protobuf, application/xml;q=0.1", &book{}, contentTypeXML},
+		{"should be json for browsers", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", &book{}, contentTypeJSON},
+		{"should be the explicit type over the wildcard of lower quality", "application/xml, */*;q=0.1", &book{}, contentTypeXML},
+		{"should be the explicit type over the application wildcard", "application/xml, application/*;q=0.1", &book{}, contentTypeXML},
+		{"should be json for the wildcard of higher quality", "application/xml;q=0.5, */*", &book{}, contentTypeJSON},
+		{"should be json if the preferred type is not supported", "text/csv, application/yaml;q=0.9, */*;q=0.1", &book{}, contentTypeJSON},
+		{"should skip the wildcard of zero quality", "application/xml, */*;q=0", &book{}, contentTypeXML},
+	}
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			assert.Equal(t, d.expected, negotiate(d.accept, d.data))
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("should encode in xml", func(t *testing.T) {
+		body, err := encode(contentTypeXML, &book{Title: "hiboot"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "<book><title>hiboot</title></book>", string(body))
+	})
+
+	t.Run("should encode in yaml", func(t *testing.T) {
+		body, err := encode(contentTypeYAML, []book{{Title: "hiboot"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "- title: hiboot\n", string(body))
+	})
+
+	t.Run("should encode in protobuf", func(t *testing.T) {
+		body, err := encode(contentTypeProtobuf, &greeting{Message: "hi"})
+		assert.Equal(t, nil, err)
+		g := new(greeting)
+		err = proto.Unmarshal(body, g)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "hi", g.Message)
+	})
+
+	t.Run("should not encode unsupported content type", func(t *testing.T) {
+		_, err := encode(contentTypeJSON, &book{})
+		assert.Equal(t, ErrUnsupportedType, err)
+	})
+}
@@ -0,0 +1,412 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/fatih/camelcase"
+	"github.com/hidevopsio/hiboot/pkg/app/web/openapi"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
+	"github.com/hidevopsio/hiboot/pkg/utils/str"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	schemaRefPrefix      = "#/components/schemas/"
+	contentTypeText      = "text/plain"
+	contentTypeHTML      = "text/html"
+	contentTypeForm      = "application/x-www-form-urlencoded"
+	contentTypeMultipart = "multipart/form-data"
+	tagSummary           = "summary"
+	tagDescription       = "description"
+	tagExample           = "example"
+	tagControllerName    = "tag"
+)
+
+// route is the registered route of controller method, which is documented as the operation
+type route struct {
+	method      string
+	path        string
+	tag         string
+	operationID string
+	doc         reflect.StructTag
+	hdl         *handler
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// openAPIGenerator generates the OpenAPI document from the routes
+type openAPIGenerator struct {
+	schemas map[string]*openapi.Schema
+	// names are the names of the schemas of the named structs in components
+	names map[reflect.Type]string
+}
+
+// generateOpenAPI generates the OpenAPI document of the routes
+func generateOpenAPI(conf system.OpenAPI, tags []openapi.Tag, routes []route) *openapi.Document {
+	g := &openAPIGenerator{schemas: make(map[string]*openapi.Schema), names: make(map[reflect.Type]string)}
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       conf.Title,
+			Description: conf.Description,
+			Version:     conf.Version,
+		},
+		Tags:  tags,
+		Paths: make(map[string]*openapi.PathItem),
+	}
+	for _, r := range routes {
+		pathItem, ok := doc.Paths[r.path]
+		if !ok {
+			pathItem = &openapi.PathItem{}
+			doc.Paths[r.path] = pathItem
+		}
+		(*pathItem)[strings.ToLower(r.method)] = g.operation(r)
+	}
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+// operation documents the route, the summary and description are overridden by the doc tags
+func (g *openAPIGenerator) operation(r route) *openapi.Operation {
+	methodName := r.hdl.method.Name
+	op := &openapi.Operation{
+		Summary:     strings.Join(camelcase.Split(methodName), " "),
+		Description: r.doc.Get(tagDescription),
+		OperationID: r.operationID,
+		Responses:   make(map[string]*openapi.Response),
+	}
+	if summary, ok := r.doc.Lookup(tagSummary); ok {
+		op.Summary = summary
+	}
+	if r.tag != "" {
+		op.Tags = []string{r.tag}
+	}
+
+	h := r.hdl
+	for i := 1; i < h.numIn; i++ {
+		req := h.requests[i]
+		if i-1 < h.lenOfPathParams {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:     req.name,
+				In:       "path",
+				Required: true,
+				Schema:   g.schema(req.typ),
+			})
+			continue
+		}
+		if req.paramName != "" {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:   req.paramName,
+				In:     tagQuery,
+				Schema: g.schema(req.typ),
+			})
+			continue
+		}
+		switch req.typeName {
+		case model.RequestTypeBody:
+			op.RequestBody = g.requestBody(contentTypeJSON, req.typ)
+		case model.RequestTypeForm:
+			op.RequestBody = g.requestBody(contentTypeForm, req.typ)
+		case model.RequestTypeMultipart:
+			op.RequestBody = g.requestBody(contentTypeMultipart, req.typ)
+		case model.RequestTypeParams:
+			op.Parameters = append(op.Parameters, g.parameters(req.iTyp, tagQuery, true)...)
+		case model.RequestTypeHeader:
+			op.Parameters = append(op.Parameters, g.parameters(req.iTyp, tagHeader, true)...)
+		case model.RequestTypeCookie:
+			op.Parameters = append(op.Parameters, g.parameters(req.iTyp, tagCookie, true)...)
+		}
+		if req.hasBindingTags && req.typeName != model.RequestTypeParams &&
+			req.typeName != model.RequestTypeHeader && req.typeName != model.RequestTypeCookie {
+			for _, tag := range bindingTags {
+				op.Parameters = append(op.Parameters, g.parameters(req.iTyp, tag, false)...)
+			}
+		}
+	}
+
+	g.responses(op, r)
+	return op
+}
+
+func (g *openAPIGenerator) requestBody(contentType string, typ reflect.Type) *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required: true,
+		Content:  map[string]*openapi.MediaType{contentType: {Schema: g.schema(typ)}},
+	}
+}
+
+// parameters documents the fields of typ as the parameters in query, header or cookie, the name of parameter is
+// given by the tag or the field name if all is true
+func (g *openAPIGenerator) parameters(typ reflect.Type, in string, all bool) (params []openapi.Parameter) {
+	for _, f := range reflector.DeepFields(typ) {
+		if f.Anonymous || f.PkgPath != "" {
+			continue
+		}
+		name, ok := f.Tag.Lookup(in)
+		if !ok && !all {
+			continue
+		}
+		name = strings.Split(name, ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+			if in == tagQuery {
+				name = str.LowerFirst(name)
+			}
+		}
+		params = append(params, openapi.Parameter{
+			Name:        name,
+			In:          in,
+			Description: f.Tag.Get(tagDescription),
+			Required:    isRequired(f),
+			Schema:      g.fieldSchema(f),
+		})
+	}
+	return
+}
+
+// responses documents the response of the results, the error response is added if the method returns error
+func (g *openAPIGenerator) responses(op *openapi.Operation, r route) {
+	h := r.hdl
+	ok := &openapi.Response{Description: http.StatusText(http.StatusOK)}
+	op.Responses[strconv.Itoa(http.StatusOK)] = ok
+	hasError := false
+	for _, resp := range h.responses {
+		if resp.typeName == "error" {
+			hasError = true
+		}
+	}
+	if hasError {
+		op.Responses["default"] = &openapi.Response{
+			Description: "Error",
+			Content:     map[string]*openapi.MediaType{contentTypeJSON: {Schema: g.baseResponse()}},
+		}
+	}
+	if h.numOut == 0 {
+		return
+	}
+
+	resp := h.responses[0]
+	if isEventStream(resp.typ) {
+		ok.Content = map[string]*openapi.MediaType{contentTypeEventStream: {Schema: &openapi.Schema{Type: "string"}}}
+		return
+	}
+	if isStream(resp.typ) {
+		ok.Content = map[string]*openapi.MediaType{contentTypeOctetStream: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}
+		return
+	}
+	if isView(resp.typ) {
+		ok.Content = map[string]*openapi.MediaType{contentTypeHTML: {Schema: &openapi.Schema{Type: "string"}}}
+		return
+	}
+	// the response is detected by its type instead of the name as the handler does
+	switch {
+	case resp.typeName == "error":
+		return
+	case resp.typeName == "string":
+		ok.Content = map[string]*openapi.MediaType{contentTypeText: {Schema: &openapi.Schema{Type: "string"}}}
+	case resp.typ == responseType:
+		ok.Content = map[string]*openapi.MediaType{contentTypeJSON: {Schema: g.baseResponse()}}
+	case isResponse(resp.typ):
+		ok.Content = map[string]*openapi.MediaType{contentTypeJSON: {Schema: g.schema(resp.typ)}}
+	default:
+		schema := g.schema(resp.typ)
+		if h.wrapResponse {
+			schema = &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"code":    {Type: "integer", Format: "int32"},
+					"message": {Type: "string"},
+					"data":    schema,
+				},
+			}
+		}
+		ok.Content = make(map[string]*openapi.MediaType)
+		for _, contentType := range []string{contentTypeJSON, contentTypeXML, contentTypeYAML} {
+			ok.Content[contentType] = &openapi.MediaType{Schema: schema}
+		}
+	}
+}
+
+// responseType is the type of interface model.Response
+var responseType = reflect.TypeOf((*model.Response)(nil)).Elem()
+
+// isResponse check if typ or its pointer implements model.Response
+func isResponse(typ reflect.Type) bool {
+	return typ.Implements(responseType) || (typ.Kind() != reflect.Ptr && reflect.PtrTo(typ).Implements(responseType))
+}
+
+func (g *openAPIGenerator) baseResponse() *openapi.Schema {
+	return g.schema(reflect.TypeOf(model.BaseResponse{}))
+}
+
+// schema returns the schema of typ, the named struct is referenced to the schema in components
+func (g *openAPIGenerator) schema(typ reflect.Type) *openapi.Schema {
+	switch typ {
+	case fileHeaderType:
+		return &openapi.Schema{Type: "string", Format: "binary"}
+	case fileHeadersType:
+		return &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string", Format: "binary"}}
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return &openapi.Schema{Type: "string"}
+	case reflect.Bool:
+		return &openapi.Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openapi.Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &openapi.Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &openapi.Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openapi.Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &openapi.Schema{Type: "string", Format: "byte"}
+		}
+		return &openapi.Schema{Type: "array", Items: g.schema(typ.Elem())}
+	case reflect.Map:
+		return &openapi.Schema{Type: "object", AdditionalProperties: g.schema(typ.Elem())}
+	case reflect.Struct:
+		if typ == timeType {
+			return &openapi.Schema{Type: "string", Format: "date-time"}
+		}
+		if typ.Name() == "" {
+			return g.structSchema(typ)
+		}
+		name, ok := g.names[typ]
+		if !ok {
+			name = g.schemaName(typ)
+			// reserve the name first in case the struct references itself
+			g.names[typ] = name
+			g.schemas[name] = &openapi.Schema{}
+			*g.schemas[name] = *g.structSchema(typ)
+		}
+		return &openapi.Schema{Ref: schemaRefPrefix + name}
+	}
+	// any type
+	return &openapi.Schema{}
+}
+
+// schemaName returns the name of struct typ in components, which is qualified by the package path if the name is
+// taken by the struct of another package
+func (g *openAPIGenerator) schemaName(typ reflect.Type) string {
+	name := typ.Name()
+	if _, ok := g.schemas[name]; ok {
+		name = strings.Replace(typ.PkgPath(), "/", ".", -1) + "." + name
+	}
+	return name
+}
+
+// structSchema returns the object schema of the exported fields of struct typ, the embedded structs are flattened
+func (g *openAPIGenerator) structSchema(typ reflect.Type) *openapi.Schema {
+	schema := &openapi.Schema{Type: "object", Properties: make(map[string]*openapi.Schema)}
+	for _, f := range reflector.DeepFields(typ) {
+		if f.Anonymous || f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tag = strings.Split(tag, ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		schema.Properties[name] = g.fieldSchema(f)
+		if isRequired(f) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of the field with the description, example and the constraints of validate tag
+func (g *openAPIGenerator) fieldSchema(f reflect.StructField) *openapi.Schema {
+	schema := g.schema(f.Type)
+	if schema.Ref != "" {
+		// the siblings of $ref are ignored
+		return schema
+	}
+	schema.Description = f.Tag.Get(tagDescription)
+	schema.Example = f.Tag.Get(tagExample)
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		kv := strings.SplitN(rule, "=", 2)
+		switch kv[0] {
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "min", "gte", "max", "lte", "len":
+			if len(kv) == 2 {
+				applyConstraint(schema, kv[0], kv[1])
+			}
+		}
+	}
+	return schema
+}
+
+// applyConstraint applies the min, max or len rule to the schema of string, number or array
+func applyConstraint(schema *openapi.Schema, rule, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	isMin := rule == "min" || rule == "gte" || rule == "len"
+	isMax := rule == "max" || rule == "lte" || rule == "len"
+	size := int(n)
+	switch schema.Type {
+	case "string":
+		if isMin {
+			schema.MinLength = &size
+		}
+		if isMax {
+			schema.MaxLength = &size
+		}
+	case "array":
+		if isMin {
+			schema.MinItems = &size
+		}
+		if isMax {
+			schema.MaxItems = &size
+		}
+	case "integer", "number":
+		if isMin {
+			schema.Minimum = &n
+		}
+		if isMax {
+			schema.Maximum = &n
+		}
+	}
+}
+
+// isRequired check if the field is required by validate tag
+func isRequired(f reflect.StructField) bool {
+	return str.InSlice("required", strings.Split(f.Tag.Get("validate"), ","))
+}
@@ -0,0 +1,244 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app/web/openapi"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type petRequest struct {
+	model.RequestBody
+	Name string   `json:"name" validate:"required,min=2" description:"name of the pet" example:"kitty"`
+	Age  int      `json:"age" validate:"gte=0,lte=30"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+type petParams struct {
+	model.RequestParams
+	Page int    `validate:"gte=1"`
+	Kind string `query:"type"`
+}
+
+type pet struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Owner *pet   `json:"owner"`
+}
+
+type petController struct {
+	Controller `tag:"pets" description:"Pet store"`
+}
+
+func (c *petController) Documentation() map[string]string {
+	return map[string]string{
+		"GetById": `summary:"Find pet" description:"Find pet by id"`,
+	}
+}
+
+func (c *petController) GetById(id int) (*pet, error) {
+	return &pet{ID: id}, nil
+}
+
+func (c *petController) Get(params *petParams) []pet {
+	return nil
+}
+
+func (c *petController) Post(request *petRequest) (model.Response, error) {
+	return new(model.BaseResponse), nil
+}
+
+type petResponse struct {
+	model.BaseResponse
+}
+
+func (c *petController) PutById(id int) (*petResponse, error) {
+	return new(petResponse), nil
+}
+
+// Response is named Response but does not implement model.Response
+type Response struct {
+	Status string `json:"status"`
+}
+
+func (c *petController) GetStatus() Response {
+	return Response{}
+}
+
+func newRoute(t *testing.T, controller interface{}, method, methodName, path string) route {
+	m, ok := reflect.TypeOf(controller).MethodByName(methodName)
+	assert.Equal(t, true, ok)
+	hdl := new(handler)
+	hdl.parse(m, controller, path)
+	docs := controller.(Documenter).Documentation()
+	return route{method: method, path: path, tag: "pets", operationID: "petController." + methodName,
+		doc: reflect.StructTag(docs[methodName]), hdl: hdl}
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	controller := new(petController)
+	d := new(dispatcher)
+	tag := d.documentController(reflect.TypeOf(controller).Elem(), "pet")
+	assert.Equal(t, "pets", tag)
+	assert.Equal(t, []openapi.Tag{{Name: "pets", Description: "Pet store"}}, d.tags)
+
+	doc := generateOpenAPI(system.OpenAPI{Title: "petstore", Version: "1.0.0"}, d.tags, []route{
+		newRoute(t, controller, http.MethodGet, "GetById", "/pet/id/{id}"),
+		newRoute(t, controller, http.MethodGet, "Get", "/pet"),
+		newRoute(t, controller, http.MethodPost, "Post", "/pet"),
+		newRoute(t, controller, http.MethodPut, "PutById", "/pet/id/{id}"),
+		newRoute(t, controller, http.MethodGet, "GetStatus", "/pet/status"),
+	})
+
+	t.Run("should generate info", func(t *testing.T) {
+		assert.Equal(t, openapi.Version, doc.OpenAPI)
+		assert.Equal(t, "petstore", doc.Info.Title)
+		assert.Equal(t, "1.0.0", doc.Info.Version)
+	})
+
+	t.Run("should document path params and override summary", func(t *testing.T) {
+		op := (*doc.Paths["/pet/id/{id}"])["get"]
+		assert.Equal(t, "Find pet", op.Summary)
+		assert.Equal(t, "Find pet by id", op.Description)
+		assert.Equal(t, []string{"pets"}, op.Tags)
+		assert.Equal(t, []openapi.Parameter{{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "integer", Format: "int64"}}}, op.Parameters)
+		assert.Equal(t, schemaRefPrefix+"pet", op.Responses["200"].Content[contentTypeJSON].Schema.Ref)
+		assert.Equal(t, schemaRefPrefix+"BaseResponse", op.Responses["default"].Content[contentTypeJSON].Schema.Ref)
+	})
+
+	t.Run("should document query params", func(t *testing.T) {
+		op := (*doc.Paths["/pet"])["get"]
+		assert.Equal(t, "Get", op.Summary)
+		assert.Equal(t, 2, len(op.Parameters))
+		assert.Equal(t, "page", op.Parameters[0].Name)
+		assert.Equal(t, "query", op.Parameters[0].In)
+		assert.Equal(t, float64(1), *op.Parameters[0].Schema.Minimum)
+		assert.Equal(t, "type", op.Parameters[1].Name)
+		assert.Equal(t, "array", op.Responses["200"].Content[contentTypeJSON].Schema.Type)
+	})
+
+	t.Run("should document request body", func(t *testing.T) {
+		op := (*doc.Paths["/pet"])["post"]
+		assert.Equal(t, schemaRefPrefix+"petRequest", op.RequestBody.Content[contentTypeJSON].Schema.Ref)
+
+		schema := doc.Components.Schemas["petRequest"]
+		assert.Equal(t, []string{"name"}, schema.Required)
+		assert.Equal(t, "name of the pet", schema.Properties["name"].Description)
+		assert.Equal(t, "kitty", schema.Properties["name"].Example)
+		assert.Equal(t, 2, *schema.Properties["name"].MinLength)
+		assert.Equal(t, float64(30), *schema.Properties["age"].Maximum)
+		assert.Equal(t, &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string"}}, schema.Properties["tags"])
+	})
+
+	t.Run("should document model.Response by its type", func(t *testing.T) {
+		op := (*doc.Paths["/pet"])["post"]
+		assert.Equal(t, schemaRefPrefix+"BaseResponse", op.Responses["200"].Content[contentTypeJSON].Schema.Ref)
+
+		op = (*doc.Paths["/pet/id/{id}"])["put"]
+		assert.Equal(t, schemaRefPrefix+"petResponse", op.Responses["200"].Content[contentTypeJSON].Schema.Ref)
+		assert.Equal(t, 1, len(op.Responses["200"].Content))
+	})
+
+	t.Run("should not document the type named Response as model.Response", func(t *testing.T) {
+		op := (*doc.Paths["/pet/status"])["get"]
+		assert.Equal(t, schemaRefPrefix+"Response", op.Responses["200"].Content[contentTypeJSON].Schema.Ref)
+	})
+
+	t.Run("should reference the recursive struct", func(t *testing.T) {
+		assert.Equal(t, schemaRefPrefix+"pet", doc.Components.Schemas["pet"].Properties["owner"].Ref)
+	})
+}
+
+func TestSchemaName(t *testing.T) {
+	type BaseResponse struct {
+		Message string `json:"message"`
+	}
+	g := &openAPIGenerator{schemas: make(map[string]*openapi.Schema), names: make(map[reflect.Type]string)}
+
+	t.Run("should reference the schema by the struct name", func(t *testing.T) {
+		assert.Equal(t, schemaRefPrefix+"BaseResponse", g.baseResponse().Ref)
+	})
+
+	t.Run("should qualify the name of the struct of another package", func(t *testing.T) {
+		name := "github.com.hidevopsio.hiboot.pkg.app.web.BaseResponse"
+		assert.Equal(t, schemaRefPrefix+name, g.schema(reflect.TypeOf(BaseResponse{})).Ref)
+		assert.Equal(t, []string{"message"}, keysOf(g.schemas[name].Properties))
+	})
+
+	t.Run("should reuse the schema of the same struct", func(t *testing.T) {
+		assert.Equal(t, schemaRefPrefix+"BaseResponse", g.baseResponse().Ref)
+		assert.Equal(t, 2, len(g.schemas))
+	})
+}
+
+func keysOf(m map[string]*openapi.Schema) (keys []string) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	a := new(testApplication)
+	err := a.initialize(new(petController))
+	assert.Equal(t, nil, err)
+	a.SystemConfig().OpenAPI.Enabled = true
+	a.expect, err = a.RunTestServer(t)
+	assert.Equal(t, nil, err)
+
+	t.Run("should serve OpenAPI document", func(t *testing.T) {
+		a.Get("/openapi.json").
+			Expect().Status(http.StatusOK).
+			JSON().Object().Value("paths").Object().ContainsKey("/pet/id/{id}")
+	})
+
+	t.Run("should serve Swagger UI", func(t *testing.T) {
+		a.Get("/swagger-ui").
+			Expect().Status(http.StatusOK).
+			Body().Contains("/openapi.json")
+	})
+
+	t.Run("should load the assets of Swagger UI from cdn by default", func(t *testing.T) {
+		a.Get("/swagger-ui").
+			Expect().Status(http.StatusOK).
+			Body().Contains("https://cdn.jsdelivr.net/npm/swagger-ui-dist@4.15.5/swagger-ui-bundle.js")
+	})
+
+	t.Run("should not serve the assets of Swagger UI by default", func(t *testing.T) {
+		a.Get("/swagger-ui/swagger-ui-bundle.js").
+			Expect().Status(http.StatusNotFound)
+	})
+}
+
+func TestServeSelfHostedSwaggerUI(t *testing.T) {
+	a := new(testApplication)
+	err := a.initialize(new(petController))
+	assert.Equal(t, nil, err)
+	a.SystemConfig().OpenAPI.Enabled = true
+	a.SystemConfig().OpenAPI.UIAssets = "/swagger-ui-dist/"
+	a.expect, err = a.RunTestServer(t)
+	assert.Equal(t, nil, err)
+
+	t.Run("should load the assets of Swagger UI from the configured base url", func(t *testing.T) {
+		a.Get("/swagger-ui").
+			Expect().Status(http.StatusOK).
+			Body().Contains("/swagger-ui-dist/swagger-ui-bundle.js")
+	})
+}
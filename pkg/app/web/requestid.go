@@ -0,0 +1,82 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/utils/idgen"
+	"github.com/kataras/iris/context"
+	"net/http"
+	"runtime/debug"
+)
+
+const (
+	// RequestIDHeader is the header of the request id, which is read from the request or generated, and responded
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// requestIDHandler reads the request id from X-Request-ID header or generates a new one by idgen, the request id
+// is stored on the context and responded in X-Request-ID header
+func requestIDHandler(ctx context.Context) {
+	c := ctx.(*Context)
+	requestID := c.GetHeader(RequestIDHeader)
+	if !isValidRequestID(requestID) {
+		var err error
+		requestID, err = idgen.NextString()
+		if err != nil {
+			log.Warnf("[web] failed to generate request id: %v", err)
+		}
+	}
+	c.requestID = requestID
+	if requestID != "" {
+		c.Header(RequestIDHeader, requestID)
+	}
+	ctx.Next()
+}
+
+// isValidRequestID reports whether the request id from client is not empty, not too long and printable, so that
+// it can be written in log lines safely
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// recoverHandler recovers from the panic of the handlers after it, the panic is logged with the request id and
+// responded as internal server error
+func recoverHandler(ctx context.Context) {
+	defer func() {
+		if err := recover(); err != nil {
+			// the aborted handler is handled by net/http
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			Log(ctx).Errorf("[web] recovered from panic: %v\n%s", err, debug.Stack())
+			if !ctx.IsStopped() {
+				ctx.StatusCode(http.StatusInternalServerError)
+				ctx.StopExecution()
+			}
+		}
+	}()
+	ctx.Next()
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestIsValidRequestID(t *testing.T) {
+	testData := []struct {
+		requestID string
+		expected  bool
+	}{
+		{"", false},
+		{"123", true},
+		{"f47ac10b-58cc-4372-a567-0e02b2c3d479", true},
+		{"123\n[456] forged", false},
+		{"12 3", false},
+		{strings.Repeat("1", maxRequestIDLength), true},
+		{strings.Repeat("1", maxRequestIDLength+1), false},
+	}
+	for _, data := range testData {
+		t.Run(data.requestID, func(t *testing.T) {
+			assert.Equal(t, data.expected, isValidRequestID(data.requestID))
+		})
+	}
+}
+
+func TestContextLog(t *testing.T) {
+	ctx := &Context{requestID: "123"}
+	assert.Equal(t, "123", ctx.RequestID())
+	assert.NotEqual(t, nil, ctx.Log())
+	assert.NotEqual(t, nil, Log(ctx))
+	assert.NotEqual(t, nil, Log(nil))
+}
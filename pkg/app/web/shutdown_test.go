@@ -0,0 +1,150 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/kataras/iris"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type connectionPool struct {
+	closed bool
+}
+
+func (p *connectionPool) Destroy() {
+	p.closed = true
+}
+
+func TestShutdown(t *testing.T) {
+	a := new(testApplication)
+	err := a.initialize(new(FooController))
+	assert.Equal(t, nil, err)
+	a.expect, err = a.RunTestServer(t)
+	assert.Equal(t, nil, err)
+	pool := new(connectionPool)
+	a.ConfigurableFactory().SetInstance("connectionPool", pool)
+	defer app.SetReady(true)
+
+	t.Run("should mark readiness down", func(t *testing.T) {
+		err := a.shutdown(system.Shutdown{Delay: "0s", Timeout: "invalid"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, app.IsReady())
+		assert.Equal(t, false, pool.closed)
+	})
+}
+
+type slowController struct {
+	Controller
+	started chan struct{}
+}
+
+func (c *slowController) Get() string {
+	close(c.started)
+	time.Sleep(500 * time.Millisecond)
+	return "done"
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+	controller := &slowController{started: make(chan struct{})}
+	a := new(testApplication)
+	err := a.initialize(controller)
+	assert.Equal(t, nil, err)
+	err = a.build()
+	assert.Equal(t, nil, err)
+	defer app.SetReady(true)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	addr := listener.Addr().String()
+	go a.webApp.Run(iris.Listener(listener), iris.WithoutInterruptHandler, iris.WithoutStartupLog)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		responses <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-controller.started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- a.shutdown(system.Shutdown{Delay: "0s", Timeout: "5s"})
+	}()
+
+	t.Run("should refuse new connections while the active request is running", func(t *testing.T) {
+		refused := false
+		for deadline := time.Now().Add(400 * time.Millisecond); time.Now().Before(deadline); {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				refused = true
+				break
+			}
+			conn.Close()
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, true, refused)
+		assert.Equal(t, 0, len(responses))
+	})
+
+	t.Run("should complete the active request", func(t *testing.T) {
+		r := <-responses
+		assert.Equal(t, nil, r.err)
+		assert.Equal(t, http.StatusOK, r.status)
+		assert.Contains(t, r.body, "done")
+		assert.Equal(t, nil, <-shutdown)
+	})
+}
+
+func TestRunDestroys(t *testing.T) {
+	// the port is in use, so that the server stops at once
+	listener, err := net.Listen("tcp", ":0")
+	assert.Equal(t, nil, err)
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	for _, graceful := range []bool{true, false} {
+		t.Run(fmt.Sprintf("should call destroy hooks if the server fails to start, graceful: %v", graceful), func(t *testing.T) {
+			a := new(testApplication)
+			err := a.initialize(new(FooController))
+			assert.Equal(t, nil, err)
+			a.SystemConfig().Server.Port = port
+			a.SystemConfig().Server.Shutdown.Graceful = &graceful
+			pool := new(connectionPool)
+			a.ConfigurableFactory().SetInstance("connectionPool", pool)
+
+			err = a.Run()
+			assert.NotEqual(t, nil, err)
+			assert.Equal(t, true, pool.closed)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultIndex = "index.html"
+
+var (
+	fileSystems   = make(map[string]http.FileSystem)
+	fileSystemsMu sync.RWMutex
+)
+
+// AddFileSystem adds the file system of name, e.g. the embedded assets, which is served by the static assets
+// whose file_system is name
+func AddFileSystem(name string, fs http.FileSystem) {
+	fileSystemsMu.Lock()
+	defer fileSystemsMu.Unlock()
+	fileSystems[name] = fs
+}
+
+// staticMount serves the assets of the file system at the path
+type staticMount struct {
+	conf  system.Static
+	path  string
+	index string
+	fs    http.FileSystem
+}
+
+func newStaticMount(conf system.Static) (*staticMount, error) {
+	m := &staticMount{conf: conf, path: cleanPath(pathSep + conf.Path), index: conf.Index}
+	if m.index == "" {
+		m.index = defaultIndex
+	}
+	if conf.FileSystem != "" {
+		fileSystemsMu.RLock()
+		fs, ok := fileSystems[conf.FileSystem]
+		fileSystemsMu.RUnlock()
+		if !ok {
+			return nil, &system.ErrNotFound{Name: "file system " + conf.FileSystem}
+		}
+		m.fs = fs
+		return m, nil
+	}
+	if info, err := os.Stat(conf.Dir); err != nil || !info.IsDir() {
+		return nil, &system.ErrNotFound{Name: "static directory " + conf.Dir}
+	}
+	m.fs = http.Dir(conf.Dir)
+	return m, nil
+}
+
+// relative returns the path of the asset relative to the mount, ok is false if the path is not in the mount
+// or is excluded
+func (m *staticMount) relative(p string) (rel string, ok bool) {
+	for _, prefix := range m.conf.Exclude {
+		if hasPathPrefix(p, prefix) {
+			return
+		}
+	}
+	if !hasPathPrefix(p, m.path) {
+		return
+	}
+	return cleanPath(pathSep + strings.TrimPrefix(p, m.path)), true
+}
+
+// hasPathPrefix reports whether p is prefix or in it
+func hasPathPrefix(p, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, pathSep)
+	return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+pathSep)
+}
+
+// open opens the file of name, or the index file if it is a directory, the directories are not listed
+func (m *staticMount) open(name string) (f http.File, info os.FileInfo, filename string) {
+	for _, filename = range []string{name, path.Join(name, m.index)} {
+		var err error
+		if f, err = m.fs.Open(filename); err != nil {
+			return nil, nil, ""
+		}
+		if info, err = f.Stat(); err == nil && !info.IsDir() {
+			return
+		}
+		f.Close()
+	}
+	return nil, nil, ""
+}
+
+// serve serves the asset of name, it returns false if the asset is not found
+func (m *staticMount) serve(w http.ResponseWriter, r *http.Request, name string) bool {
+	f, info, filename := m.open(name)
+	if f == nil {
+		return false
+	}
+	defer f.Close()
+
+	header := w.Header()
+	// the index file refers to the assets of the new version once it is deployed, so it is revalidated
+	if path.Base(filename) == m.index {
+		header.Set("Cache-Control", "no-cache")
+	} else if m.conf.CacheControl != "" {
+		header.Set("Cache-Control", m.conf.CacheControl)
+	}
+
+	if m.conf.Precompressed {
+		addVary(header, "Accept-Encoding")
+		for _, variant := range []struct{ ext, encoding string }{{".br", "br"}, {".gz", "gzip"}} {
+			if !acceptsEncoding(r, variant.encoding) {
+				continue
+			}
+			cf, err := m.fs.Open(filename + variant.ext)
+			if err != nil {
+				continue
+			}
+			defer cf.Close()
+			ci, err := cf.Stat()
+			if err != nil || ci.IsDir() {
+				continue
+			}
+			// the content type is of the original asset rather than the compressed one
+			header.Set("Content-Type", contentType(filename, f))
+			header.Set("Content-Encoding", variant.encoding)
+			http.ServeContent(w, r, filename, ci.ModTime(), cf)
+			return true
+		}
+	}
+
+	http.ServeContent(w, r, filename, info.ModTime(), f)
+	return true
+}
+
+// contentType returns the content type by the extension of name, or by the content of f
+func contentType(name string, f io.ReadSeeker) string {
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		return ctype
+	}
+	var buf [512]byte
+	n, _ := io.ReadFull(f, buf[:])
+	f.Seek(0, io.SeekStart)
+	return http.DetectContentType(buf[:n])
+}
+
+// acceptsEncoding reports whether the encoding is accepted by Accept-Encoding header of the request
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(accepted, ";")
+		if name := strings.TrimSpace(params[0]); name != encoding && name != "*" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && kv[0] == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isNavigation reports whether the request is the navigation of browser to the route of single page app
+func isNavigation(r *http.Request, rel string) bool {
+	return path.Ext(rel) == "" && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// notFoundWriter discards the not found response of the router, so that the index file can be served instead
+type notFoundWriter struct {
+	http.ResponseWriter
+	notFound bool
+}
+
+func (w *notFoundWriter) WriteHeader(code int) {
+	if code == http.StatusNotFound {
+		w.notFound = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *notFoundWriter) Write(b []byte) (int, error) {
+	if w.notFound {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// serveStatic serves the static assets before the routes, the requests whose assets are not found are routed,
+// and the html requests that are not routed either are responded with the index file if fallback is enabled
+func serveStatic(mounts []*staticMount, w http.ResponseWriter, r *http.Request, router http.HandlerFunc) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		router(w, r)
+		return
+	}
+	var fallback *staticMount
+	for _, m := range mounts {
+		rel, ok := m.relative(r.URL.Path)
+		if !ok {
+			continue
+		}
+		if m.serve(w, r, rel) {
+			return
+		}
+		if fallback == nil && m.conf.Fallback && isNavigation(r, rel) {
+			fallback = m
+		}
+	}
+	if fallback == nil {
+		router(w, r)
+		return
+	}
+
+	nfw := &notFoundWriter{ResponseWriter: w}
+	router(nfw, r)
+	if nfw.notFound {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		fallback.serve(w, r, pathSep)
+	}
+}
+
+// serveStatic serves the static assets of the properties server.static
+func (a *application) serveStatic(confs []system.Static) error {
+	var mounts []*staticMount
+	for _, conf := range confs {
+		m, err := newStaticMount(conf)
+		if err != nil {
+			return err
+		}
+		mounts = append(mounts, m)
+	}
+	if len(mounts) == 0 {
+		return nil
+	}
+	a.webApp.WrapRouter(func(w http.ResponseWriter, r *http.Request, router http.HandlerFunc) {
+		serveStatic(mounts, w, r, router)
+	})
+	return nil
+}
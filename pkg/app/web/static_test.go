@@ -0,0 +1,168 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newStaticDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "static")
+	assert.Equal(t, nil, err)
+	files := map[string]string{
+		"index.html":      "<html>app</html>",
+		"app.js":          "console.log('app')",
+		"app.js.gz":       "gzipped app",
+		"docs/index.html": "<html>docs</html>",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+		assert.Equal(t, nil, os.MkdirAll(filepath.Dir(name), 0755))
+		assert.Equal(t, nil, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+	return dir
+}
+
+// router responds the api and not found for other paths
+func router(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/user":
+		w.Write([]byte("user"))
+	case "/api/missing":
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":404}`))
+	default:
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Not Found"))
+	}
+}
+
+func TestServeStatic(t *testing.T) {
+	dir := newStaticDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := newStaticMount(system.Static{
+		Path:          "/",
+		Dir:           dir,
+		Fallback:      true,
+		Exclude:       []string{"/api"},
+		CacheControl:  "public, max-age=31536000",
+		Precompressed: true,
+	})
+	assert.Equal(t, nil, err)
+	mounts := []*staticMount{m}
+
+	serve := func(method, target string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		serveStatic(mounts, w, r, router)
+		return w
+	}
+
+	t.Run("should serve the asset with cache headers", func(t *testing.T) {
+		w := serve(http.MethodGet, "/app.js", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "console.log('app')", w.Body.String())
+		assert.Equal(t, "public, max-age=31536000", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	})
+
+	t.Run("should serve the precompressed variant", func(t *testing.T) {
+		w := serve(http.MethodGet, "/app.js", map[string]string{"Accept-Encoding": "br;q=0, gzip"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzipped app", w.Body.String())
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	})
+
+	t.Run("should serve the index file of directory", func(t *testing.T) {
+		w := serve(http.MethodGet, "/docs/", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>docs</html>", w.Body.String())
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("should fall back to the index file for the routes of single page app", func(t *testing.T) {
+		w := serve(http.MethodGet, "/user/123", map[string]string{"Accept": "text/html,application/xhtml+xml"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<html>app</html>", w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	})
+
+	t.Run("should not fall back for the requests other than html", func(t *testing.T) {
+		w := serve(http.MethodGet, "/user/123", map[string]string{"Accept": "application/json"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = serve(http.MethodGet, "/missing.js", map[string]string{"Accept": "text/html"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should route the excluded api", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/user", nil)
+		assert.Equal(t, "user", w.Body.String())
+		w = serve(http.MethodGet, "/api/missing", map[string]string{"Accept": "text/html"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, `{"code":404}`, w.Body.String())
+	})
+
+	t.Run("should route the requests other than GET and HEAD", func(t *testing.T) {
+		w := serve(http.MethodPost, "/app.js", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should not serve the files outside of the directory", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path = "/../" + filepath.Base(dir) + "/app.js"
+		w := httptest.NewRecorder()
+		serveStatic(mounts, w, r, router)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestNewStaticMount(t *testing.T) {
+	t.Run("should report the directory that is not found", func(t *testing.T) {
+		_, err := newStaticMount(system.Static{Path: "/", Dir: "/not/found"})
+		assert.Equal(t, "static directory /not/found is not found", err.Error())
+	})
+
+	t.Run("should serve the added file system", func(t *testing.T) {
+		dir := newStaticDir(t)
+		defer os.RemoveAll(dir)
+		AddFileSystem("assets", http.Dir(dir))
+
+		m, err := newStaticMount(system.Static{Path: "/assets", FileSystem: "assets"})
+		assert.Equal(t, nil, err)
+		rel, ok := m.relative("/assets/app.js")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "/app.js", rel)
+		_, ok = m.relative("/assetsx/app.js")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should report the file system that is not found", func(t *testing.T) {
+		_, err := newStaticMount(system.Static{Path: "/", FileSystem: "unknown"})
+		assert.Equal(t, "file system unknown is not found", err.Error())
+	})
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeEventStream = "text/event-stream"
+	contentTypeOctetStream = "application/octet-stream"
+
+	chunkSize = 32 << 10
+)
+
+var (
+	readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	eventType  = reflect.TypeOf(model.Event{})
+
+	// drainTimeout is the longest time that the channel is drained after the client disconnects, the producer that
+	// does not stop on the done channel of the request context and close the channel in time is abandoned
+	drainTimeout = time.Minute
+
+	// newlines normalizes the line breaks of the event data, removeNewlines removes them from the event id and name,
+	// which are single line fields
+	newlines       = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+	removeNewlines = strings.NewReplacer("\r\n", "", "\r", "", "\n", "")
+)
+
+// isStream check if the return type of controller method is a stream, which is a receivable channel
+// or io.Reader
+func isStream(typ reflect.Type) bool {
+	return isEventStream(typ) || typ.Implements(readerType)
+}
+
+// isEventStream check if typ is a receivable channel, e.g. <-chan model.Event, the values are sent as
+// server-sent events
+func isEventStream(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Chan && typ.ChanDir()&reflect.RecvDir != 0
+}
+
+// writeStream writes the stream to the response until the stream ends or the client disconnects
+func writeStream(w http.ResponseWriter, done <-chan struct{}, stream reflect.Value) error {
+	if isEventStream(stream.Type()) {
+		return writeEvents(w, done, stream)
+	}
+	return writeChunks(w, done, stream.Interface().(io.Reader))
+}
+
+// writeEvents writes the values received from the channel as server-sent events, each event is flushed to the client
+// immediately, it returns when the channel is closed or done is closed by the disconnection of the client, then the
+// remaining values are drained until the channel is closed or drainTimeout, so that the producer blocked on sending
+// is released
+func writeEvents(w http.ResponseWriter, done <-chan struct{}, events reflect.Value) error {
+	header := w.Header()
+	header.Set("Content-Type", contentTypeEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable the response buffering of the proxy such as nginx
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+		{Dir: reflect.SelectRecv, Chan: events},
+	}
+	for {
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 0 {
+			go drain(events, drainTimeout)
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		var event model.Event
+		if value.Type() == eventType {
+			event = value.Interface().(model.Event)
+		} else {
+			event.Data = value.Interface()
+		}
+		if err := writeEvent(w, &event); err != nil {
+			go drain(events, drainTimeout)
+			return err
+		}
+		flush(w)
+	}
+}
+
+// drain receives and discards the values of the channel until it is closed or the timeout expires
+func drain(ch reflect.Value, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+	}
+	for {
+		chosen, _, ok := reflect.Select(cases)
+		if chosen == 1 {
+			log.Warnf("the event stream is not closed in %v after the client disconnects, "+
+				"the producer should stop on the done channel of the request context and close the channel", timeout)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// writeEvent writes the event in the format of text/event-stream, the multiline data is sent in multiple data fields,
+// and the line breaks in the id and name are removed, so that they can not inject other fields or events
+func writeEvent(w io.Writer, event *model.Event) (err error) {
+	var data string
+	switch d := event.Data.(type) {
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		var b []byte
+		if b, err = json.Marshal(d); err != nil {
+			return
+		}
+		data = string(b)
+	}
+
+	var sb strings.Builder
+	if id := removeNewlines.Replace(event.ID); id != "" {
+		fmt.Fprintf(&sb, "id: %v\n", id)
+	}
+	if name := removeNewlines.Replace(event.Name); name != "" {
+		fmt.Fprintf(&sb, "event: %v\n", name)
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&sb, "retry: %v\n", event.Retry)
+	}
+	for _, line := range strings.Split(newlines.Replace(data), "\n") {
+		fmt.Fprintf(&sb, "data: %v\n", line)
+	}
+	sb.WriteString("\n")
+	_, err = io.WriteString(w, sb.String())
+	return
+}
+
+// writeChunks copies the reader to the response in chunked transfer encoding, each chunk is flushed to the client,
+// the reader is closed at the end if it is io.Closer, so that the producer of the reader such as io.Pipe is
+// notified when the client disconnects
+func writeChunks(w http.ResponseWriter, done <-chan struct{}, r io.Reader) (err error) {
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", contentTypeOctetStream)
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			if _, err = w.Write(buf[:n]); err != nil {
+				return
+			}
+			flush(w)
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestIsStream(t *testing.T) {
+	assert.Equal(t, true, isStream(reflect.TypeOf((<-chan model.Event)(nil))))
+	assert.Equal(t, true, isStream(reflect.TypeOf((chan string)(nil))))
+	assert.Equal(t, false, isStream(reflect.TypeOf((chan<- string)(nil))))
+	assert.Equal(t, true, isStream(reflect.TypeOf((*io.Reader)(nil)).Elem()))
+	assert.Equal(t, true, isStream(reflect.TypeOf(new(bytes.Buffer))))
+	assert.Equal(t, false, isStream(reflect.TypeOf("")))
+}
+
+func TestWriteEvent(t *testing.T) {
+	t.Run("should write the event with all fields", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := writeEvent(buf, &model.Event{ID: "1", Name: "greeting", Data: "hello\nworld", Retry: 3000})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "id: 1\nevent: greeting\nretry: 3000\ndata: hello\ndata: world\n\n", buf.String())
+	})
+
+	t.Run("should write the data in json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := writeEvent(buf, &model.Event{Data: book{Title: "hiboot"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "data: {\"title\":\"hiboot\"}\n\n", buf.String())
+	})
+
+	t.Run("should remove the line breaks of id and name", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := writeEvent(buf, &model.Event{ID: "1\r\ndata: x", Name: "greeting\n\nevent: x\r", Data: "hello"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "id: 1data: x\nevent: greetingevent: x\ndata: hello\n\n", buf.String())
+	})
+
+	t.Run("should split the data on all kinds of line breaks", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := writeEvent(buf, &model.Event{Data: "a\r\nb\rc\nd"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "data: a\ndata: b\ndata: c\ndata: d\n\n", buf.String())
+	})
+}
+
+func TestWriteEvents(t *testing.T) {
+	t.Run("should write the events until the channel is closed", func(t *testing.T) {
+		events := make(chan model.Event, 2)
+		events <- model.Event{ID: "1", Data: "foo"}
+		events <- model.Event{ID: "2", Data: "bar"}
+		close(events)
+		w := httptest.NewRecorder()
+		err := writeEvents(w, nil, reflect.ValueOf(events))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, contentTypeEventStream, w.Header().Get("Content-Type"))
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		assert.Equal(t, true, w.Flushed)
+		assert.Equal(t, "id: 1\ndata: foo\n\nid: 2\ndata: bar\n\n", w.Body.String())
+	})
+
+	t.Run("should write the values of other types as the event data", func(t *testing.T) {
+		values := make(chan int, 1)
+		values <- 42
+		close(values)
+		w := httptest.NewRecorder()
+		err := writeEvents(w, nil, reflect.ValueOf(values))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "data: 42\n\n", w.Body.String())
+	})
+
+	t.Run("should stop writing when the client disconnects", func(t *testing.T) {
+		done := make(chan struct{})
+		events := make(chan model.Event)
+		result := make(chan error)
+		go func() {
+			result <- writeEvents(httptest.NewRecorder(), done, reflect.ValueOf(events))
+		}()
+		events <- model.Event{Data: "foo"}
+		close(done)
+		select {
+		case err := <-result:
+			assert.Equal(t, nil, err)
+		case <-time.After(time.Second):
+			t.Error("writeEvents is not cancelled")
+		}
+	})
+
+	t.Run("should release the producer when the client disconnects", func(t *testing.T) {
+		done := make(chan struct{})
+		events := make(chan model.Event)
+		finished := make(chan struct{})
+		// the producer does not select on done, so it is blocked on sending unless the events are drained
+		go func() {
+			defer close(finished)
+			defer close(events)
+			for i := 0; i < 3; i++ {
+				events <- model.Event{Data: i}
+				if i == 0 {
+					close(done)
+				}
+			}
+		}()
+		err := writeEvents(httptest.NewRecorder(), done, reflect.ValueOf(events))
+		assert.Equal(t, nil, err)
+		select {
+		case <-finished:
+		case <-time.After(time.Second):
+			t.Error("the producer is not released")
+		}
+	})
+}
+
+func TestDrain(t *testing.T) {
+	t.Run("should stop draining the channel that is never closed", func(t *testing.T) {
+		events := make(chan model.Event)
+		finished := make(chan struct{})
+		go func() {
+			drain(reflect.ValueOf(events), 10*time.Millisecond)
+			close(finished)
+		}()
+		events <- model.Event{Data: "foo"}
+		select {
+		case <-finished:
+		case <-time.After(time.Second):
+			t.Error("drain is not stopped")
+		}
+	})
+}
+
+func TestWriteChunks(t *testing.T) {
+	t.Run("should copy the reader and close it", func(t *testing.T) {
+		content := strings.Repeat("hiboot", chunkSize)
+		r := &closeRecorder{Reader: strings.NewReader(content)}
+		w := httptest.NewRecorder()
+		err := writeChunks(w, nil, r)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, content, w.Body.String())
+		assert.Equal(t, contentTypeOctetStream, w.Header().Get("Content-Type"))
+		assert.Equal(t, true, w.Flushed)
+		assert.Equal(t, true, r.closed)
+	})
+
+	t.Run("should keep the content type that is set by controller", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", "text/csv")
+		err := writeChunks(w, nil, strings.NewReader("a,b"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("should stop writing and close the reader when the client disconnects", func(t *testing.T) {
+		done := make(chan struct{})
+		close(done)
+		pr, pw := io.Pipe()
+		go pw.Write([]byte("foo"))
+		w := httptest.NewRecorder()
+		err := writeChunks(w, done, pr)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", w.Body.String())
+		_, err = pw.Write([]byte("bar"))
+		assert.Equal(t, io.ErrClosedPipe, err)
+	})
+
+	t.Run("should return the error of reader", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		pw.CloseWithError(http.ErrAbortHandler)
+		err := writeChunks(httptest.NewRecorder(), nil, pr)
+		assert.Equal(t, http.ErrAbortHandler, err)
+	})
+}
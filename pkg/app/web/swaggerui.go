@@ -0,0 +1,85 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"github.com/hidevopsio/hiboot/pkg/app/web/openapi"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/kataras/iris/context"
+	"html/template"
+	"strings"
+	"sync"
+)
+
+// swaggerUI is the page of Swagger UI that loads the OpenAPI document
+var swaggerUI = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" type="text/css" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+<script src="{{.Assets}}/swagger-ui-standalone-preset.js"></script>
+<script>
+  window.onload = function () {
+    window.ui = SwaggerUIBundle({
+      url: {{.Path}},
+      dom_id: "#swagger-ui",
+      deepLinking: true,
+      presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+      layout: "StandaloneLayout"
+    });
+  };
+</script>
+</body>
+</html>
+`))
+
+// serveOpenAPI serves the OpenAPI document that is generated from the registered controllers and the Swagger UI,
+// the assets of Swagger UI are loaded from the configured base url, which is the cdn by default
+func (a *application) serveOpenAPI(conf system.OpenAPI) {
+	// the document is generated on the first request, when all the controllers are registered
+	var once sync.Once
+	var doc *openapi.Document
+	a.webApp.Get(conf.Path, func(ctx context.Context) {
+		once.Do(func() {
+			doc = generateOpenAPI(conf, a.dispatcher.tags, a.dispatcher.routes)
+		})
+		ctx.JSON(doc)
+	})
+
+	if conf.UIPath == "" {
+		return
+	}
+	var page bytes.Buffer
+	err := swaggerUI.Execute(&page, map[string]string{
+		"Title":  conf.Title,
+		"Assets": strings.TrimSuffix(conf.UIAssets, "/"),
+		"Path":   conf.Path,
+	})
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	a.webApp.Get(conf.UIPath, func(ctx context.Context) {
+		ctx.ContentType(context.ContentHTMLHeaderValue)
+		ctx.Write(page.Bytes())
+	})
+}
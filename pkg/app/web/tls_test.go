@@ -0,0 +1,212 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert generates the self-signed certificate, or the certificate signed by parent
+func newTestCert(t *testing.T, dir, name string, parent *testCert, isCA bool, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	err = ioutil.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	assert.Equal(t, nil, err)
+	err = ioutil.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	assert.Equal(t, nil, err)
+	return tc
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hiboot-tls")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, dir, "ca", nil, true, x509.ExtKeyUsageAny)
+	server := newTestCert(t, dir, "server", ca, false, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, dir, "client", ca, false, x509.ExtKeyUsageClientAuth)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	conf := system.TLS{
+		Enabled:      true,
+		CertFile:     server.certFile,
+		KeyFile:      server.keyFile,
+		MinVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		ClientAuth:   "require_and_verify",
+		ClientCAFile: ca.certFile,
+		HTTP2:        true,
+	}
+
+	t.Run("should create tls config", func(t *testing.T) {
+		config, err := newTLSConfig(conf)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+		assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, config.CipherSuites)
+		assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+		assert.Equal(t, []string{"h2", "http/1.1"}, config.NextProtos)
+	})
+
+	t.Run("should verify the client certificates if they are required", func(t *testing.T) {
+		c := conf
+		c.ClientAuth = "require"
+		config, err := newTLSConfig(c)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+		c.ClientAuth = "require_any"
+		c.ClientCAFile = ""
+		config, err = newTLSConfig(c)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tls.RequireAnyClientCert, config.ClientAuth)
+	})
+
+	t.Run("should report invalid properties", func(t *testing.T) {
+		for _, c := range []system.TLS{
+			{CertFile: "not-exist.crt", KeyFile: "not-exist.key"},
+			{CertFile: server.certFile, KeyFile: server.keyFile, MinVersion: "2.0"},
+			{CertFile: server.certFile, KeyFile: server.keyFile, CipherSuites: []string{"TLS_UNKNOWN"}},
+			{CertFile: server.certFile, KeyFile: server.keyFile, ClientAuth: "always"},
+			{CertFile: server.certFile, KeyFile: server.keyFile, ClientCAFile: server.keyFile},
+			{CertFile: server.certFile, KeyFile: server.keyFile, ClientAuth: "require"},
+			{CertFile: server.certFile, KeyFile: server.keyFile, ClientAuth: "verify_if_given"},
+			{CertFile: server.certFile, KeyFile: server.keyFile, ClientAuth: "require_and_verify"},
+		} {
+			_, err := newTLSConfig(c)
+			assert.NotEqual(t, nil, err)
+		}
+	})
+
+	ln, err := newTLSListener("127.0.0.1:0", conf)
+	assert.Equal(t, nil, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+	url := "https://" + ln.Addr().String()
+
+	clientCert, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+	assert.Equal(t, nil, err)
+
+	t.Run("should serve HTTP/2 with client certificate", func(t *testing.T) {
+		c := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}},
+			ForceAttemptHTTP2: true,
+		}}
+		resp, err := c.Get(url)
+		assert.Equal(t, nil, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "HTTP/2.0", string(body))
+	})
+
+	t.Run("should reject the client without certificate", func(t *testing.T) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+		_, err := c.Get(url)
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestCloseRedirectServer(t *testing.T) {
+	a := new(application)
+	t.Run("should do nothing without redirect server", func(t *testing.T) {
+		a.closeRedirectServer()
+	})
+
+	t.Run("should close the redirect server", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Equal(t, nil, err)
+		a.redirectServer = &http.Server{Handler: redirectHandler("8443")}
+		done := make(chan error, 1)
+		go func() {
+			done <- a.redirectServer.Serve(ln)
+		}()
+		a.closeRedirectServer()
+		assert.Equal(t, http.ErrServerClosed, <-done)
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	testData := []struct {
+		port     string
+		target   string
+		expected string
+	}{
+		{"8443", "http://example.com:8080/foo?bar=baz", "https://example.com:8443/foo?bar=baz"},
+		{"443", "http://example.com/foo", "https://example.com/foo"},
+	}
+	for _, d := range testData {
+		w := httptest.NewRecorder()
+		redirectHandler(d.port).ServeHTTP(w, httptest.NewRequest(http.MethodGet, d.target, nil))
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, d.expected, w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	versioningPath      = "path"
+	versioningHeader    = "header"
+	versioningMediaType = "media_type"
+
+	defaultVersionHeader = "X-API-Version"
+
+	tagVersion    = "version"
+	tagDeprecated = "deprecated"
+	tagSunset     = "sunset"
+)
+
+// apiVersion is the version of controller given by the tags of the embedded controller, e.g.
+//
+//	type userControllerV1 struct {
+//		web.Controller `version:"v1" deprecated:"true" sunset:"Sat, 01 Jun 2019 00:00:00 GMT"`
+//	}
+type apiVersion struct {
+	// name is the version in the form of v1, which is the path prefix of the controller if strategy is path
+	name string
+	// deprecated responds Deprecation header, and Sunset header if sunset is given
+	deprecated bool
+	sunset     string
+}
+
+// versionOf returns the version of the controller type, or nil if it is not versioned
+func versionOf(typ reflect.Type) *apiVersion {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+		name := normalizeVersion(f.Tag.Get(tagVersion))
+		if name == "" {
+			continue
+		}
+		v := &apiVersion{name: "v" + name, sunset: f.Tag.Get(tagSunset)}
+		v.deprecated, _ = strconv.ParseBool(f.Tag.Get(tagDeprecated))
+		v.deprecated = v.deprecated || v.sunset != ""
+		return v
+	}
+	return nil
+}
+
+// deprecation responds the deprecation headers of the deprecated version
+func (v *apiVersion) deprecation(ctx context.Context) {
+	ctx.Header("Deprecation", "true")
+	if v.sunset != "" {
+		ctx.Header("Sunset", v.sunset)
+	}
+	ctx.Next()
+}
+
+// normalizeVersion returns the version without v prefix, e.g. 2 of v2
+func normalizeVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	return strings.TrimPrefix(version, "v")
+}
+
+// strategy returns the versioning strategy, path by default
+func (d *dispatcher) strategy() string {
+	if d.versioning.Strategy == "" {
+		return versioningPath
+	}
+	return d.versioning.Strategy
+}
+
+// isDefault reports whether version is the default version
+func (d *dispatcher) isDefault(version *apiVersion) bool {
+	return version != nil && "v"+normalizeVersion(d.versioning.DefaultVersion) == version.name
+}
+
+// routedByVersion reports whether the routes of the version are shared with other versions and routed by
+// the version requested in header or media type
+func (d *dispatcher) routedByVersion(version *apiVersion) bool {
+	return version != nil && d.strategy() != versioningPath
+}
+
+// versionedContextMappings returns the context mappings of the controller of version, the controller of default
+// version is also served on the context mapping without version prefix if strategy is path
+func (d *dispatcher) versionedContextMappings(contextMapping string, version *apiVersion) []string {
+	if version == nil || d.strategy() != versioningPath {
+		return []string{contextMapping}
+	}
+	mappings := []string{pathSep + version.name + contextMapping}
+	if d.isDefault(version) {
+		mappings = append(mappings, contextMapping)
+	}
+	return mappings
+}
+
+// handle registers the handlers of the route, the handlers of the versions that are routed by version are
+// registered on the same route which dispatches the request to the handlers of the requested version
+func (d *dispatcher) handle(party iris.Party, method, contextMapping, p string, version *apiVersion,
+	handlers context.Handlers, name string) {
+	if version != nil && version.deprecated {
+		handlers = append(context.Handlers{version.deprecation}, handlers...)
+	}
+	if !d.routedByVersion(version) {
+		r := party.Handle(method, p, handlers...)
+		if name != "" {
+			r.MainHandlerName = name
+		}
+		return
+	}
+
+	key := method + " " + cleanPath(contextMapping+p)
+	vr, ok := d.versionedRoutes[key]
+	if !ok {
+		vr = &versionedRoute{dispatcher: d, handlers: make(map[string]context.Handlers)}
+		if d.versionedRoutes == nil {
+			d.versionedRoutes = make(map[string]*versionedRoute)
+		}
+		d.versionedRoutes[key] = vr
+		party.Handle(method, p, vr.serve)
+	}
+	vr.handlers[version.name] = handlers
+}
+
+// versionedRoute dispatches the request to the handlers of the requested version
+type versionedRoute struct {
+	dispatcher *dispatcher
+	handlers   map[string]context.Handlers
+}
+
+func (vr *versionedRoute) serve(ctx context.Context) {
+	d := vr.dispatcher
+	header := d.versioning.Header
+	if header == "" {
+		header = defaultVersionHeader
+	}
+	var requested string
+	if d.strategy() == versioningMediaType {
+		ctx.ResponseWriter().Header().Add("Vary", "Accept")
+		requested = mediaTypeVersion(ctx.GetHeader("Accept"))
+	} else {
+		ctx.ResponseWriter().Header().Add("Vary", header)
+		requested = ctx.GetHeader(header)
+	}
+
+	version := normalizeVersion(requested)
+	if version == "" {
+		version = normalizeVersion(d.versioning.DefaultVersion)
+	}
+	handlers, ok := vr.handlers["v"+version]
+	if !ok {
+		var err error = &system.ErrUnsupportedVersion{Version: requested}
+		if version == "" {
+			err = &system.ErrBadRequest{Message: "api version is required"}
+		}
+		ctx.(*Context).ResponseErr(err)
+		ctx.StopExecution()
+		return
+	}
+
+	// run the handlers of the version in place of this handler
+	chain := ctx.Handlers()
+	idx := ctx.HandlerIndex(-1)
+	spliced := make(context.Handlers, 0, len(chain)+len(handlers))
+	spliced = append(spliced, chain[:idx+1]...)
+	spliced = append(spliced, handlers...)
+	spliced = append(spliced, chain[idx+1:]...)
+	ctx.SetHandlers(spliced)
+	ctx.Next()
+}
+
+// mediaTypeVersion returns the version in the media types of Accept header, which is given by the version parameter
+// or the vendor media type, e.g. application/json; version=2 or application/vnd.hiboot.v2+json
+func mediaTypeVersion(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		if v, ok := params[tagVersion]; ok {
+			return v
+		}
+		slash := strings.Index(mediaType, pathSep)
+		if slash < 0 || !strings.HasPrefix(mediaType[slash+1:], "vnd.") {
+			continue
+		}
+		subtype := strings.SplitN(mediaType[slash+1:], "+", 2)[0]
+		segments := strings.Split(subtype, ".")
+		last := segments[len(segments)-1]
+		if len(last) > 1 && last[0] == 'v' && last[1] >= '0' && last[1] <= '9' {
+			return last
+		}
+	}
+	return ""
+}
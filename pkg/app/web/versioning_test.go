@@ -0,0 +1,99 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type fooController struct {
+	Controller
+}
+
+type fooV1Controller struct {
+	Controller `version:"1" deprecated:"true"`
+}
+
+type fooV2Controller struct {
+	Controller `version:"v2" sunset:"Sat, 01 Jun 2019 00:00:00 GMT"`
+}
+
+type fooV3Controller struct {
+	Controller `version:"V3"`
+}
+
+func TestVersionOf(t *testing.T) {
+	testData := []struct {
+		typ      reflect.Type
+		expected *apiVersion
+	}{
+		{reflect.TypeOf(fooController{}), nil},
+		{reflect.TypeOf(fooV1Controller{}), &apiVersion{name: "v1", deprecated: true}},
+		{reflect.TypeOf(fooV2Controller{}), &apiVersion{name: "v2", deprecated: true, sunset: "Sat, 01 Jun 2019 00:00:00 GMT"}},
+		{reflect.TypeOf(fooV3Controller{}), &apiVersion{name: "v3"}},
+	}
+	for _, data := range testData {
+		t.Run(data.typ.Name(), func(t *testing.T) {
+			assert.Equal(t, data.expected, versionOf(data.typ))
+		})
+	}
+}
+
+func TestVersionedContextMappings(t *testing.T) {
+	v1 := &apiVersion{name: "v1"}
+	v2 := &apiVersion{name: "v2"}
+
+	t.Run("should prefix context mapping with version", func(t *testing.T) {
+		d := &dispatcher{versioning: system.Versioning{DefaultVersion: "v2"}}
+		assert.Equal(t, []string{"/foo"}, d.versionedContextMappings("/foo", nil))
+		assert.Equal(t, []string{"/v1/foo"}, d.versionedContextMappings("/foo", v1))
+		assert.Equal(t, []string{"/v2/foo", "/foo"}, d.versionedContextMappings("/foo", v2))
+		assert.Equal(t, false, d.routedByVersion(v1))
+	})
+
+	t.Run("should route by header on the same context mapping", func(t *testing.T) {
+		d := &dispatcher{versioning: system.Versioning{Strategy: versioningHeader}}
+		assert.Equal(t, []string{"/foo"}, d.versionedContextMappings("/foo", v1))
+		assert.Equal(t, true, d.routedByVersion(v1))
+		assert.Equal(t, false, d.routedByVersion(nil))
+	})
+}
+
+func TestMediaTypeVersion(t *testing.T) {
+	testData := []struct {
+		accept   string
+		expected string
+	}{
+		{"application/json", ""},
+		{"application/json; version=2", "2"},
+		{"application/vnd.hiboot.v2+json", "v2"},
+		{"text/html, application/vnd.hiboot.v3+json;q=0.9", "v3"},
+		{"application/vnd.hiboot+json", ""},
+	}
+	for _, data := range testData {
+		t.Run(data.accept, func(t *testing.T) {
+			assert.Equal(t, data.expected, mediaTypeVersion(data.accept))
+		})
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	assert.Equal(t, "2", normalizeVersion(" V2 "))
+	assert.Equal(t, "2", normalizeVersion("2"))
+	assert.Equal(t, "", normalizeVersion(""))
+}
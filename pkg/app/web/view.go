@@ -0,0 +1,63 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"reflect"
+)
+
+const viewDataLanguage = "lang"
+
+// viewOf returns the view model of the response, which is model.View or *model.View
+func viewOf(response interface{}) (*model.View, bool) {
+	switch v := response.(type) {
+	case model.View:
+		return &v, true
+	case *model.View:
+		return v, v != nil
+	}
+	return nil, false
+}
+
+// isView reports whether the response type is model.View or *model.View
+func isView(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ == reflect.TypeOf(model.View{})
+}
+
+// render renders the view model by the template engine that is registered by the view starter, the language of
+// the request is added to the data if it is a map, so that it can be passed to the i18n function tr of the engine
+func (ctx *Context) render(v *model.View) error {
+	if v.Layout != "" {
+		ctx.ViewLayout(v.Layout)
+	}
+	data := v.Data
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if m, ok := data.(map[string]interface{}); ok {
+		viewData := make(map[string]interface{}, len(m)+1)
+		for k, val := range m {
+			viewData[k] = val
+		}
+		languageKey := ctx.Application().ConfigurationReadOnly().GetTranslateLanguageContextKey()
+		viewData[viewDataLanguage] = ctx.Values().GetString(languageKey)
+		data = viewData
+	}
+	return ctx.View(v.Name, data)
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func TestViewOf(t *testing.T) {
+	t.Run("should get view model", func(t *testing.T) {
+		v, ok := viewOf(model.View{Name: "index.html"})
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "index.html", v.Name)
+
+		v, ok = viewOf(&model.View{Name: "user.html"})
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "user.html", v.Name)
+	})
+
+	t.Run("should not get view model", func(t *testing.T) {
+		_, ok := viewOf((*model.View)(nil))
+		assert.Equal(t, false, ok)
+		_, ok = viewOf("index.html")
+		assert.Equal(t, false, ok)
+	})
+}
+
+func TestIsView(t *testing.T) {
+	assert.Equal(t, true, isView(reflect.TypeOf(model.View{})))
+	assert.Equal(t, true, isView(reflect.TypeOf(&model.View{})))
+	assert.Equal(t, false, isView(reflect.TypeOf(model.Event{})))
+}
+
+func TestViewRegistry(t *testing.T) {
+	_, ok := interface{}(new(application)).(ViewRegistry)
+	assert.Equal(t, true, ok)
+}
@@ -0,0 +1,508 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/hidevopsio/hiboot/pkg/utils/idgen"
+	"github.com/hidevopsio/hiboot/pkg/utils/validator"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	onConnectMethod    = "OnConnect"
+	onMessageMethod    = "OnMessage"
+	onDisconnectMethod = "OnDisconnect"
+
+	writeWait             = 10 * time.Second
+	defaultSendBufferSize = 256
+)
+
+// ErrConnClosed the websocket connection is closed
+var ErrConnClosed = errors.New("[web] websocket connection is closed")
+
+var (
+	connType  = reflect.TypeOf((*Conn)(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// AnonWebSocketController is the anonymous websocket controller interface
+type AnonWebSocketController interface{}
+
+// WebSocketController is the base websocket controller, the upgrade request is served on GET of its context mapping,
+// and the methods of the controller are dispatched by convention:
+//
+//	OnConnect(conn *web.Conn) error is called after the connection is upgraded, it is closed if error is returned
+//	OnMessage(conn *web.Conn, message T) (R, error) is called on each message, T is string, []byte or the type
+//	that the message is decoded into from json, the non-nil R is sent back, and the error is sent as model.BaseResponse
+//	OnDisconnect(conn *web.Conn) is called after the connection is closed
+//
+// The controller is shared by all connections, the state of each connection should be kept in the conn
+type WebSocketController struct {
+	AnonWebSocketController
+	ContextMapping string
+	hub            *Hub
+}
+
+// Hub returns the hub of the connections of the controller
+func (c *WebSocketController) Hub() *Hub {
+	return c.hub
+}
+
+func (c *WebSocketController) setHub(hub *Hub) {
+	c.hub = hub
+}
+
+// hubHolder is implemented by the controllers that embed WebSocketController
+type hubHolder interface {
+	setHub(hub *Hub)
+}
+
+// Hub is the connections of a websocket controller, the connections join rooms to receive the broadcast messages
+type Hub struct {
+	mu    sync.RWMutex
+	conns map[*Conn]struct{}
+	rooms map[string]map[*Conn]struct{}
+}
+
+func newHub() *Hub {
+	return &Hub{
+		conns: make(map[*Conn]struct{}),
+		rooms: make(map[string]map[*Conn]struct{}),
+	}
+}
+
+// Broadcast sends the message to the connections in the room, or all connections of the hub if room is empty
+func (h *Hub) Broadcast(room string, message interface{}) error {
+	mt, data, err := encodeMessage(message)
+	if err != nil {
+		return err
+	}
+	for _, c := range h.Conns(room) {
+		c.send(mt, data)
+	}
+	return nil
+}
+
+// Conns returns the connections in the room, or all connections of the hub if room is empty
+func (h *Hub) Conns(room string) (conns []*Conn) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	members := h.conns
+	if room != "" {
+		members = h.rooms[room]
+	}
+	for c := range members {
+		conns = append(conns, c)
+	}
+	return
+}
+
+// Rooms returns the sorted names of the rooms that have connections
+func (h *Hub) Rooms() (rooms []string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return
+}
+
+func (h *Hub) add(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[c] = struct{}{}
+}
+
+func (h *Hub) remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c)
+	for room := range c.rooms {
+		h.leave(c, room)
+	}
+}
+
+func (h *Hub) join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.rooms[room]; !ok {
+		h.rooms[room] = make(map[*Conn]struct{})
+	}
+	h.rooms[room][c] = struct{}{}
+	c.rooms[room] = struct{}{}
+}
+
+// leave removes c from the room, the caller must hold the lock
+func (h *Hub) leave(c *Conn, room string) {
+	delete(c.rooms, room)
+	if members, ok := h.rooms[room]; ok {
+		delete(members, c)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+type message struct {
+	typ  int
+	data []byte
+}
+
+// Conn is the websocket connection
+type Conn struct {
+	// Ctx is the context of the upgrade request, it is valid until the connection is closed
+	Ctx *Context
+
+	id       string
+	ws       *websocket.Conn
+	hub      *Hub
+	rooms    map[string]struct{}
+	messages chan message
+	done     chan struct{}
+
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+}
+
+// ID returns the unique id of the connection
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// Send sends the message to the client, the string is sent as text message, []byte as binary message,
+// and other types are sent in json
+func (c *Conn) Send(message interface{}) error {
+	mt, data, err := encodeMessage(message)
+	if err != nil {
+		return err
+	}
+	return c.send(mt, data)
+}
+
+// send queues the message, the connection is closed if its buffer is full as the client is too slow
+func (c *Conn) send(mt int, data []byte) error {
+	select {
+	case <-c.done:
+		return ErrConnClosed
+	default:
+	}
+	select {
+	case c.messages <- message{typ: mt, data: data}:
+		return nil
+	default:
+		c.closeWith(websocket.CloseTryAgainLater, "too slow to receive messages")
+		return ErrConnClosed
+	}
+}
+
+// sendError sends the error as model.BaseResponse with the mapped status, error code and message
+func (c *Conn) sendError(err error) {
+	status, code, msg := mapError(nil, err)
+	if c.Ctx != nil {
+		status, code, msg = c.Ctx.mapError(err)
+		msg = c.Ctx.translate(msg)
+	}
+	c.Send(&model.BaseResponse{Code: status, ErrorCode: code, Message: msg})
+}
+
+// Join joins the room to receive the messages broadcast to it
+func (c *Conn) Join(room string) {
+	c.hub.join(c, room)
+}
+
+// Leave leaves the room
+func (c *Conn) Leave(room string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	c.hub.leave(c, room)
+}
+
+// Rooms returns the sorted names of the rooms that the connection has joined
+func (c *Conn) Rooms() (rooms []string) {
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return
+}
+
+// Close closes the connection normally
+func (c *Conn) Close() error {
+	c.closeWith(websocket.CloseNormalClosure, "")
+	return nil
+}
+
+func (c *Conn) closeWith(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode, c.closeText = code, text
+		close(c.done)
+	})
+}
+
+// writeLoop writes the queued messages and pings until the connection is closed, it is the only writer of
+// the websocket connection
+func (c *Conn) writeLoop(pingInterval time.Duration) {
+	defer c.ws.Close()
+	var ping <-chan time.Time
+	if pingInterval > 0 {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	for {
+		select {
+		case m := <-c.messages:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(m.typ, m.data); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeText),
+				time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// webSocketHandler serves the websocket connections of the controller
+type webSocketHandler struct {
+	controller   reflect.Value
+	onConnect    *reflect.Method
+	onMessage    *reflect.Method
+	onDisconnect *reflect.Method
+	messageType  reflect.Type
+
+	upgrader       websocket.Upgrader
+	hub            *Hub
+	maxMessageSize int64
+	pingInterval   time.Duration
+	sendBufferSize int
+}
+
+func newWebSocketHandler(controller interface{}, name string, conf system.WebSocket) (h *webSocketHandler, err error) {
+	h = &webSocketHandler{
+		controller:     reflect.ValueOf(controller),
+		hub:            newHub(),
+		sendBufferSize: conf.SendBufferSize,
+		upgrader:       websocket.Upgrader{CheckOrigin: checkOrigin(conf.AllowedOrigins)},
+	}
+	if h.sendBufferSize <= 0 {
+		h.sendBufferSize = defaultSendBufferSize
+	}
+	if h.maxMessageSize, err = parseSize(conf.MaxMessageSize); err != nil {
+		return
+	}
+	if conf.PingInterval != "" {
+		if h.pingInterval, err = time.ParseDuration(conf.PingInterval); err != nil {
+			return
+		}
+	}
+
+	typ := h.controller.Type()
+	for _, methodName := range []string{onConnectMethod, onMessageMethod, onDisconnectMethod} {
+		method, ok := typ.MethodByName(methodName)
+		if !ok {
+			continue
+		}
+		mt := method.Type
+		valid := mt.NumIn() >= 2 && mt.In(1) == connType
+		switch methodName {
+		case onConnectMethod:
+			valid = valid && mt.NumIn() == 2 && (mt.NumOut() == 0 || (mt.NumOut() == 1 && mt.Out(0) == errorType))
+			h.onConnect = &method
+		case onMessageMethod:
+			valid = valid && mt.NumIn() == 3 && mt.NumOut() <= 2 && (mt.NumOut() < 2 || mt.Out(1) == errorType)
+			h.onMessage = &method
+			if valid {
+				h.messageType = mt.In(2)
+			}
+		case onDisconnectMethod:
+			valid = valid && mt.NumIn() == 2 && mt.NumOut() == 0
+			h.onDisconnect = &method
+		}
+		if !valid {
+			return nil, &system.ErrInvalidWebSocketMethod{Name: name + "." + methodName}
+		}
+	}
+
+	if holder, ok := controller.(hubHolder); ok {
+		holder.setHub(h.hub)
+	}
+	return
+}
+
+// checkOrigin returns the origin checker of the allowed origins, the same origin is allowed only if it is empty
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	if len(allowedOrigins) == 0 {
+		return nil
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, o := range allowedOrigins {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// serve upgrades the request then reads the messages until the connection is closed, ctx is kept by the connection
+// as it is not released until serve returns
+func (h *webSocketHandler) serve(w http.ResponseWriter, r *http.Request, ctx *Context) {
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has responded the error
+		ctx.Log().Warnf("[web] %v", err)
+		return
+	}
+	conn := &Conn{
+		Ctx:      ctx,
+		ws:       ws,
+		hub:      h.hub,
+		rooms:    make(map[string]struct{}),
+		messages: make(chan message, h.sendBufferSize),
+		done:     make(chan struct{}),
+	}
+	conn.id, _ = idgen.NextString()
+	go conn.writeLoop(h.pingInterval)
+
+	h.hub.add(conn)
+	defer h.hub.remove(conn)
+	if h.onConnect != nil {
+		results := h.onConnect.Func.Call([]reflect.Value{h.controller, reflect.ValueOf(conn)})
+		if len(results) == 1 && !results[0].IsNil() {
+			conn.closeWith(websocket.ClosePolicyViolation, results[0].Interface().(error).Error())
+			return
+		}
+	}
+
+	if h.maxMessageSize > 0 {
+		ws.SetReadLimit(h.maxMessageSize)
+	}
+	if h.pingInterval > 0 {
+		pongWait := 2 * h.pingInterval
+		ws.SetReadDeadline(time.Now().Add(pongWait))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(pongWait))
+		})
+	}
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		if h.onMessage != nil {
+			h.message(conn, data)
+		}
+	}
+
+	conn.Close()
+	if h.onDisconnect != nil {
+		h.onDisconnect.Func.Call([]reflect.Value{h.controller, reflect.ValueOf(conn)})
+	}
+}
+
+// message calls OnMessage with the decoded message, then sends back the result or error
+func (h *webSocketHandler) message(conn *Conn, data []byte) {
+	msg, err := decodeMessage(h.messageType, data)
+	if err != nil {
+		conn.sendError(err)
+		return
+	}
+	results := h.onMessage.Func.Call([]reflect.Value{h.controller, reflect.ValueOf(conn), msg})
+	n := len(results)
+	if n > 0 && h.onMessage.Type.Out(n-1) == errorType {
+		n--
+		if errVal := results[n]; !errVal.IsNil() {
+			conn.sendError(errVal.Interface().(error))
+			return
+		}
+	}
+	if n > 0 && !isNil(results[0]) {
+		conn.Send(results[0].Interface())
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+// decodeMessage decodes the message into typ, the struct is validated by validate tags
+func decodeMessage(typ reflect.Type, data []byte) (v reflect.Value, err error) {
+	switch {
+	case typ.Kind() == reflect.String:
+		return reflect.ValueOf(string(data)).Convert(typ), nil
+	case typ == bytesType:
+		return reflect.ValueOf(data), nil
+	}
+	iTyp := typ
+	if typ.Kind() == reflect.Ptr {
+		iTyp = typ.Elem()
+	}
+	pv := reflect.New(iTyp)
+	if err = json.Unmarshal(data, pv.Interface()); err != nil {
+		return v, &system.ErrBadRequest{Message: err.Error()}
+	}
+	if iTyp.Kind() == reflect.Struct {
+		if err = validator.Validate.Struct(pv.Interface()); err != nil {
+			return v, &system.ErrBadRequest{Message: err.Error()}
+		}
+	}
+	if typ.Kind() == reflect.Ptr {
+		return pv, nil
+	}
+	return pv.Elem(), nil
+}
+
+// encodeMessage encodes the message, the string is text message, []byte is binary message and others are in json
+func encodeMessage(v interface{}) (mt int, data []byte, err error) {
+	switch m := v.(type) {
+	case string:
+		return websocket.TextMessage, []byte(m), nil
+	case []byte:
+		return websocket.BinaryMessage, m, nil
+	}
+	data, err = json.Marshal(v)
+	return websocket.TextMessage, data, err
+}
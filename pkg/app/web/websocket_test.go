@@ -0,0 +1,268 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/hidevopsio/hiboot/pkg/model"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chatMessage struct {
+	Room string `json:"room,omitempty"`
+	Text string `json:"text"`
+}
+
+type chatController struct {
+	WebSocketController
+	disconnected chan string
+}
+
+func (c *chatController) OnConnect(conn *Conn) error {
+	conn.Join("lobby")
+	return nil
+}
+
+func (c *chatController) OnMessage(conn *Conn, message *chatMessage) (*chatMessage, error) {
+	switch {
+	case message.Room != "":
+		conn.Join(message.Room)
+		return nil, c.Hub().Broadcast(message.Room, message)
+	case message.Text == "error":
+		return nil, &system.ErrBadRequest{Message: "invalid message"}
+	}
+	return &chatMessage{Text: "echo: " + message.Text}, nil
+}
+
+func (c *chatController) OnDisconnect(conn *Conn) {
+	c.disconnected <- strings.Join(conn.Rooms(), ",")
+}
+
+type rejectController struct {
+	WebSocketController
+}
+
+func (c *rejectController) OnConnect(conn *Conn) error {
+	return errors.New("unauthorized")
+}
+
+type textController struct {
+	WebSocketController
+}
+
+func (c *textController) OnMessage(conn *Conn, message string) string {
+	return strings.ToUpper(message)
+}
+
+type invalidController struct {
+	WebSocketController
+}
+
+func (c *invalidController) OnMessage(message string) string {
+	return message
+}
+
+func newWebSocketServer(t *testing.T, controller interface{}) (*httptest.Server, *webSocketHandler) {
+	wsh, err := newWebSocketHandler(controller, "controller", system.WebSocket{PingInterval: "1s", MaxMessageSize: "1KB"})
+	assert.Equal(t, nil, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wsh.serve(w, r, nil)
+	}))
+	return srv, wsh
+}
+
+func dial(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	assert.Equal(t, nil, err)
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return ws
+}
+
+func TestWebSocketController(t *testing.T) {
+	controller := &chatController{disconnected: make(chan string, 2)}
+	srv, wsh := newWebSocketServer(t, controller)
+	defer srv.Close()
+
+	t.Run("should set the hub of controller", func(t *testing.T) {
+		assert.Equal(t, wsh.hub, controller.Hub())
+	})
+
+	alice := dial(t, srv)
+	bob := dial(t, srv)
+
+	t.Run("should echo the message", func(t *testing.T) {
+		assert.Equal(t, nil, alice.WriteJSON(&chatMessage{Text: "hello"}))
+		var reply chatMessage
+		assert.Equal(t, nil, alice.ReadJSON(&reply))
+		assert.Equal(t, "echo: hello", reply.Text)
+	})
+
+	t.Run("should send the error", func(t *testing.T) {
+		assert.Equal(t, nil, alice.WriteJSON(&chatMessage{Text: "error"}))
+		var reply model.BaseResponse
+		assert.Equal(t, nil, alice.ReadJSON(&reply))
+		assert.Equal(t, http.StatusBadRequest, reply.Code)
+		assert.Equal(t, "bad_request", reply.ErrorCode)
+		assert.Equal(t, "invalid message", reply.Message)
+	})
+
+	t.Run("should send the error of invalid message", func(t *testing.T) {
+		assert.Equal(t, nil, alice.WriteMessage(websocket.TextMessage, []byte("{")))
+		var reply model.BaseResponse
+		assert.Equal(t, nil, alice.ReadJSON(&reply))
+		assert.Equal(t, http.StatusBadRequest, reply.Code)
+	})
+
+	t.Run("should broadcast to the room", func(t *testing.T) {
+		assert.Equal(t, nil, alice.WriteJSON(&chatMessage{Room: "go", Text: "alice joined"}))
+		var reply chatMessage
+		assert.Equal(t, nil, alice.ReadJSON(&reply))
+		assert.Equal(t, "alice joined", reply.Text)
+
+		assert.Equal(t, nil, bob.WriteJSON(&chatMessage{Room: "go", Text: "bob joined"}))
+		assert.Equal(t, nil, alice.ReadJSON(&reply))
+		assert.Equal(t, "bob joined", reply.Text)
+		assert.Equal(t, nil, bob.ReadJSON(&reply))
+		assert.Equal(t, "bob joined", reply.Text)
+
+		assert.Equal(t, []string{"go", "lobby"}, wsh.hub.Rooms())
+		assert.Equal(t, 2, len(wsh.hub.Conns("go")))
+		assert.Equal(t, 2, len(wsh.hub.Conns("")))
+	})
+
+	t.Run("should call OnDisconnect and leave the rooms", func(t *testing.T) {
+		alice.Close()
+		bob.Close()
+		for i := 0; i < 2; i++ {
+			select {
+			case rooms := <-controller.disconnected:
+				assert.Equal(t, "go,lobby", rooms)
+			case <-time.After(5 * time.Second):
+				t.Error("OnDisconnect is not called")
+			}
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for len(wsh.hub.Conns("")) != 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, []string(nil), wsh.hub.Rooms())
+	})
+}
+
+func TestWebSocketReject(t *testing.T) {
+	srv, _ := newWebSocketServer(t, new(rejectController))
+	defer srv.Close()
+
+	ws := dial(t, srv)
+	_, _, err := ws.ReadMessage()
+	ce, ok := err.(*websocket.CloseError)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, websocket.ClosePolicyViolation, ce.Code)
+	assert.Equal(t, "unauthorized", ce.Text)
+}
+
+func TestWebSocketTextMessage(t *testing.T) {
+	srv, _ := newWebSocketServer(t, new(textController))
+	defer srv.Close()
+
+	ws := dial(t, srv)
+	defer ws.Close()
+	assert.Equal(t, nil, ws.WriteMessage(websocket.TextMessage, []byte("hello")))
+	mt, data, err := ws.ReadMessage()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, websocket.TextMessage, mt)
+	assert.Equal(t, "HELLO", string(data))
+
+	t.Run("should close the connection if the message is too large", func(t *testing.T) {
+		assert.Equal(t, nil, ws.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 2048))))
+		_, _, err := ws.ReadMessage()
+		assert.NotEqual(t, nil, err)
+	})
+}
+
+func TestNewWebSocketHandler(t *testing.T) {
+	t.Run("should report the invalid method", func(t *testing.T) {
+		_, err := newWebSocketHandler(new(invalidController), "invalidController", system.WebSocket{})
+		assert.Equal(t, &system.ErrInvalidWebSocketMethod{Name: "invalidController.OnMessage"}, err)
+	})
+
+	t.Run("should report the invalid ping interval", func(t *testing.T) {
+		_, err := newWebSocketHandler(new(textController), "textController", system.WebSocket{PingInterval: "often"})
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should use the default send buffer size", func(t *testing.T) {
+		wsh, err := newWebSocketHandler(new(textController), "textController", system.WebSocket{})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, defaultSendBufferSize, wsh.sendBufferSize)
+		assert.Equal(t, reflect.TypeOf(""), wsh.messageType)
+	})
+}
+
+func TestCheckOrigin(t *testing.T) {
+	newRequest := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/chat", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	assert.Equal(t, true, checkOrigin(nil) == nil)
+
+	check := checkOrigin([]string{"https://example.com"})
+	assert.Equal(t, true, check(newRequest("")))
+	assert.Equal(t, true, check(newRequest("https://EXAMPLE.com")))
+	assert.Equal(t, false, check(newRequest("https://example.org")))
+
+	assert.Equal(t, true, checkOrigin([]string{"*"})(newRequest("https://example.org")))
+}
+
+func TestEncodeDecodeMessage(t *testing.T) {
+	mt, data, err := encodeMessage("hello")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, websocket.TextMessage, mt)
+	assert.Equal(t, "hello", string(data))
+
+	mt, data, err = encodeMessage([]byte{1, 2})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, websocket.BinaryMessage, mt)
+	assert.Equal(t, []byte{1, 2}, data)
+
+	mt, data, err = encodeMessage(&chatMessage{Text: "hi"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, websocket.TextMessage, mt)
+	assert.Equal(t, `{"text":"hi"}`, string(data))
+
+	v, err := decodeMessage(reflect.TypeOf(chatMessage{}), []byte(`{"text":"hi"}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, chatMessage{Text: "hi"}, v.Interface())
+
+	v, err = decodeMessage(reflect.TypeOf([]byte(nil)), []byte("raw"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []byte("raw"), v.Interface())
+
+	_, err = decodeMessage(reflect.TypeOf(new(chatMessage)), []byte("{"))
+	_, ok := err.(*system.ErrBadRequest)
+	assert.Equal(t, true, ok)
+}
@@ -17,6 +17,7 @@ package autoconfigure
 
 import (
 	"errors"
+	"github.com/hidevopsio/hiboot/pkg/factory"
 	"github.com/hidevopsio/hiboot/pkg/factory/instantiate"
 	"github.com/hidevopsio/hiboot/pkg/inject"
 	"github.com/hidevopsio/hiboot/pkg/log"
@@ -30,7 +31,10 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -39,6 +43,21 @@ const (
 	config            = "config"
 	yaml              = "yaml"
 	appProfilesActive = "APP_PROFILES_ACTIVE"
+
+	// environment variables of the config location
+	appConfigPath = "APP_CONFIG_PATH"
+	appConfigName = "APP_CONFIG_NAME"
+	appConfigFile = "APP_CONFIG_FILE"
+
+	// FlagConfigPath is the command line flag of the config search paths
+	FlagConfigPath = "config-path"
+	// FlagConfigName is the command line flag of the config name
+	FlagConfigName = "config-name"
+	// FlagConfigFile is the command line flag of the optional config file
+	FlagConfigFile = "config-file"
+
+	// pathListSep is the separator of the config search paths
+	pathListSep = ","
 )
 
 var (
@@ -64,8 +83,21 @@ var (
 type ConfigurableFactory struct {
 	*instantiate.InstantiateFactory
 	configurations cmap.ConcurrentMap
-	systemConfig   *system.Configuration
-	builder        *system.Builder
+	// configNames are the names of the configurations in the order of creation
+	configNames []string
+	// mu guards systemConfig, which is replaced when property sources are refreshed
+	mu           sync.RWMutex
+	systemConfig *system.Configuration
+	builder      *system.Builder
+
+	configPaths []string
+	configName  string
+	configFile  string
+
+	propertySources []system.PropertySource
+	// stop stops refreshing property sources
+	stop     chan struct{}
+	stopOnce sync.Once
 
 	preConfigContainer  cmap.ConcurrentMap
 	configContainer     cmap.ConcurrentMap
@@ -86,14 +118,42 @@ func (f *ConfigurableFactory) Initialize(configurations cmap.ConcurrentMap) (err
 	f.preConfigContainer = cmap.New()
 	f.configContainer = cmap.New()
 	f.postConfigContainer = cmap.New()
+	f.stop = make(chan struct{})
 	return
 }
 
+// Destroy stops refreshing property sources, it is called on application shutdown
+func (f *ConfigurableFactory) Destroy() {
+	f.stopOnce.Do(func() {
+		if f.stop != nil {
+			close(f.stop)
+		}
+	})
+}
+
 // SystemConfiguration getter
 func (f *ConfigurableFactory) SystemConfiguration() *system.Configuration {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 	return f.systemConfig
 }
 
+// ConfigurationNames returns the names of the configurations in the order of creation, the instances of a
+// configuration are created before it
+func (f *ConfigurableFactory) ConfigurationNames() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]string(nil), f.configNames...)
+}
+
+// setConfiguration saves the configuration and records the order of creation, it should be called with mu locked
+func (f *ConfigurableFactory) setConfiguration(name string, configuration interface{}) {
+	if _, ok := f.configurations.Get(name); !ok {
+		f.configNames = append(f.configNames, name)
+	}
+	f.configurations.Set(name, configuration)
+}
+
 // Configuration getter
 func (f *ConfigurableFactory) Configuration(name string) interface{} {
 	cfg, ok := f.configurations.Get(name)
@@ -103,14 +163,166 @@ func (f *ConfigurableFactory) Configuration(name string) interface{} {
 	return nil
 }
 
+// Metadata returns the metadata of the properties of system configuration and all registered configurations,
+// including the ones that are not enabled by app.profiles.include
+func (f *ConfigurableFactory) Metadata() (metadata []system.PropertyMetadata) {
+	metadata = system.Metadata(new(system.Configuration))
+	for _, c := range []cmap.ConcurrentMap{f.preConfigContainer, f.configContainer, f.postConfigContainer} {
+		if c == nil {
+			continue
+		}
+		for item := range c.IterBuffered() {
+			metadata = append(metadata, system.Metadata(item.Val)...)
+		}
+	}
+	sort.Slice(metadata, func(i, j int) bool {
+		return metadata[i].Name < metadata[j].Name
+	})
+	return
+}
+
+// ConfigDump returns the effective properties of all configurations, the secrets are masked
+func (f *ConfigurableFactory) ConfigDump() map[string]interface{} {
+	props := make(map[string]interface{})
+	if f.configurations == nil {
+		return props
+	}
+	for item := range f.configurations.IterBuffered() {
+		for k, v := range system.Dump(item.Val) {
+			props[k] = v
+		}
+	}
+	return props
+}
+
+// SetConfigLocation set the config search paths, the config name without extension and the optional config file,
+// they take precedence over command line flags and environment variables, the empty ones are ignored
+func (f *ConfigurableFactory) SetConfigLocation(paths []string, name string, file string) {
+	f.configPaths = paths
+	f.configName = name
+	f.configFile = file
+}
+
+// lookupFlag find the value of command line flag in the form of --name=value or --name value
+func lookupFlag(name string) (value string) {
+	flag := "--" + name
+	args := os.Args[1:]
+	for i, arg := range args {
+		if strings.HasPrefix(arg, flag+"=") {
+			return arg[len(flag)+1:]
+		}
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return
+}
+
+// lookupConfig find the config location from command line flag first, then environment variable
+func lookupConfig(flag, env string) (value string) {
+	value = lookupFlag(flag)
+	if value == "" {
+		value = os.Getenv(env)
+	}
+	return
+}
+
+// splitPaths split path list and resolve relative paths against working directory
+func splitPaths(workDir string, pathList ...string) (paths []string) {
+	for _, list := range pathList {
+		for _, path := range strings.Split(list, pathListSep) {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workDir, path)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return
+}
+
+// ConfigLocation returns the config search paths, the config name and the optional config file,
+// which are set by SetConfigLocation, command line flags --config-path, --config-name and --config-file,
+// or environment variables APP_CONFIG_PATH, APP_CONFIG_NAME and APP_CONFIG_FILE in order,
+// the default location is config/application.* in working directory
+func (f *ConfigurableFactory) ConfigLocation() (paths []string, name string, file string) {
+	workDir := io.GetWorkDir()
+
+	paths = splitPaths(workDir, f.configPaths...)
+	if len(paths) == 0 {
+		paths = splitPaths(workDir, lookupConfig(FlagConfigPath, appConfigPath))
+	}
+	if len(paths) == 0 {
+		paths = []string{filepath.Join(workDir, config)}
+	}
+
+	name = f.configName
+	if name == "" {
+		name = lookupConfig(FlagConfigName, appConfigName)
+	}
+	if name == "" {
+		name = application
+	}
+
+	file = f.configFile
+	if file == "" {
+		file = lookupConfig(FlagConfigFile, appConfigFile)
+	}
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(workDir, file)
+	}
+	return
+}
+
+// LocalConfigFiles returns the candidates of the config file relative to working directory in order of precedence,
+// they are used to locate the working directory, the paths and name that are set by application properties take precedence over command line
+// flags and environment variables, ok is false if the config path is set to an absolute path
+func LocalConfigFiles(paths []string, name string) (configFiles []string, ok bool) {
+	pathList := strings.Join(paths, pathListSep)
+	if pathList == "" {
+		pathList = lookupConfig(FlagConfigPath, appConfigPath)
+	}
+	path := config
+	if p := strings.TrimSpace(strings.Split(pathList, pathListSep)[0]); p != "" {
+		path = p
+	}
+	if name == "" {
+		name = lookupConfig(FlagConfigName, appConfigName)
+	}
+	if name == "" {
+		name = application
+	}
+	if filepath.IsAbs(path) {
+		return
+	}
+	builder := &system.Builder{Path: path, Name: name, FileType: yaml}
+	return builder.ConfigFiles(name), true
+}
+
 // BuildSystemConfig build system configuration
 func (f *ConfigurableFactory) BuildSystemConfig() (systemConfig *system.Configuration, err error) {
-	workDir := io.GetWorkDir()
 	systemConfig = new(system.Configuration)
+	paths, name, file := f.ConfigLocation()
+
+	// load .env files into environment before resolving profile and placeholders
+	envFiles := []string{filepath.Join(io.GetWorkDir(), system.DotEnv)}
+	for _, path := range paths {
+		envFiles = append(envFiles, filepath.Join(path, system.DotEnv))
+	}
+	err = system.LoadEnv(envFiles...)
+	if err != nil {
+		return
+	}
+
 	profile := os.Getenv(appProfilesActive)
 	f.builder = &system.Builder{
-		Path:       filepath.Join(workDir, config),
-		Name:       application,
+		Path:       paths[0],
+		Paths:      paths[1:],
+		Name:       name,
+		ConfigFile: file,
 		FileType:   yaml,
 		Profile:    profile,
 		ConfigType: systemConfig,
@@ -122,16 +334,160 @@ func (f *ConfigurableFactory) BuildSystemConfig() (systemConfig *system.Configur
 	if err != nil {
 		return
 	}
+	// property sources are read after local config files, and the starter configurations read them as well,
+	// so they are cached to be read only once
+	var sources []system.PropertySource
+	for _, source := range f.propertySources {
+		sources = append(sources, system.NewCachedPropertySource(source))
+	}
+	for _, source := range f.configuredPropertySources(systemConfig) {
+		sources = append(sources, system.NewCachedPropertySource(source))
+	}
+	f.builder.Sources = sources
+	if len(f.builder.Sources) != 0 {
+		if e := f.builder.ReadSources(systemConfig); e != nil {
+			log.Error(e)
+		}
+		f.refreshPropertySources(systemConfig.Sources.Interval)
+	}
+
 	// TODO: should separate instance to system and app
 	inject.IntoObject(systemConfig)
 	replacer.Replace(systemConfig, systemConfig)
 
-	f.configurations.Set(System, systemConfig)
-
+	f.mu.Lock()
+	f.setConfiguration(System, systemConfig)
 	f.systemConfig = systemConfig
+	f.mu.Unlock()
 	return systemConfig, err
 }
 
+// AddPropertySource add property sources which are read after local config files, it must be called before
+// BuildSystemConfig
+func (f *ConfigurableFactory) AddPropertySource(sources ...system.PropertySource) {
+	f.propertySources = append(f.propertySources, sources...)
+}
+
+// configuredPropertySources create property sources from system configuration
+func (f *ConfigurableFactory) configuredPropertySources(systemConfig *system.Configuration) (sources []system.PropertySource) {
+	// the sources are read before the system configuration is replaced
+	if url, _ := replacer.ReplaceStringVariables(systemConfig.Sources.URL, systemConfig).(string); url != "" {
+		sources = append(sources, system.NewHTTPPropertySource(url, nil))
+	}
+	if dir, _ := replacer.ReplaceStringVariables(systemConfig.Sources.Dir, systemConfig).(string); dir != "" {
+		sources = append(sources, system.NewDirPropertySource(dir))
+	}
+	return
+}
+
+// refreshPropertySources refresh all configurations periodically if the interval is specified, the instances that
+// are already created by configurations are not refreshed, but notified if they implement factory.RefreshListener
+func (f *ConfigurableFactory) refreshPropertySources(interval string) {
+	if interval == "" {
+		return
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		log.Errorf("[factory] invalid refresh interval of property sources: %v", interval)
+		return
+	}
+	err = system.WatchPropertySources(d, f.stop, func(source system.PropertySource, props map[string]interface{}) {
+		log.Infof("[factory] refresh configurations as property source %v is changed", source.Name())
+		if f.refreshConfigurations() {
+			f.notifyRefreshListeners()
+		}
+	}, f.builder.Sources...)
+	if err != nil {
+		log.Warn(err)
+	}
+}
+
+// refreshConfigurations build the new configurations from config files and the cached property sources,
+// then replace the live ones, which are never modified, so that they are safe to read without locking
+func (f *ConfigurableFactory) refreshConfigurations() (ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	systemConfig := newConfigurationOf(f.systemConfig).(*system.Configuration)
+	if err := f.rebuild(systemConfig, nil, systemConfig); err != nil {
+		log.Warnf("[factory] failed to refresh configuration %v: %v", System, err)
+		return
+	}
+	f.systemConfig = systemConfig
+	f.configurations.Set(System, systemConfig)
+
+	for item := range f.configurations.IterBuffered() {
+		if item.Key == System {
+			continue
+		}
+		cf := newConfigurationOf(item.Val)
+		profiles := []string{item.Key, systemConfig.App.Profiles.Active}
+		if err := f.rebuild(cf, profiles, systemConfig, cf); err != nil {
+			log.Warnf("[factory] failed to refresh configuration %v: %v", item.Key, err)
+			continue
+		}
+		f.configurations.Set(item.Key, cf)
+	}
+	return true
+}
+
+// notifyRefreshListeners calls the instances that implement factory.RefreshListener after the configurations
+// are refreshed, it is called without locking so that the listeners can look up the configurations
+func (f *ConfigurableFactory) notifyRefreshListeners() {
+	notified := make(map[interface{}]bool)
+	for name, inst := range f.Items() {
+		listener, ok := inst.(factory.RefreshListener)
+		if !ok {
+			continue
+		}
+		// the same instance may be saved in different names
+		if reflect.TypeOf(listener).Comparable() {
+			if notified[listener] {
+				continue
+			}
+			notified[listener] = true
+		}
+		log.Debugf("[factory] notify %v of the refreshed configurations", name)
+		listener.OnRefresh(f)
+	}
+}
+
+// rebuild read the properties of configuration from config files and property sources, then replace
+// the references and environment variables in them by the given configurations
+func (f *ConfigurableFactory) rebuild(configuration interface{}, profiles []string, refs ...interface{}) (err error) {
+	inject.DefaultValue(configuration)
+	builder := &system.Builder{
+		Path:       f.builder.Path,
+		Paths:      f.builder.Paths,
+		Name:       f.builder.Name,
+		ConfigFile: f.builder.ConfigFile,
+		Sources:    f.builder.Sources,
+		FileType:   f.builder.FileType,
+		Profile:    f.builder.Profile,
+		ConfigType: configuration,
+	}
+	_, err = builder.Build(profiles...)
+	if err == nil {
+		inject.IntoObject(configuration)
+		replacer.Replace(configuration, refs...)
+	}
+	return
+}
+
+// newConfigurationOf returns the copy of configuration whose properties, the fields with mapstructure tag,
+// are reset, the other fields such as the injected dependencies are kept
+func newConfigurationOf(configuration interface{}) interface{} {
+	src := reflect.Indirect(reflect.ValueOf(configuration))
+	dst := reflect.New(src.Type()).Elem()
+	dst.Set(src)
+	for i := 0; i < dst.NumField(); i++ {
+		if _, ok := dst.Type().Field(i).Tag.Lookup("mapstructure"); ok && dst.Field(i).CanSet() {
+			dst.Field(i).Set(reflect.Zero(dst.Field(i).Type()))
+		}
+	}
+	return dst.Addr().Interface()
+}
+
 // Build build all auto configurations
 func (f *ConfigurableFactory) Build(configs [][]interface{}) {
 	// categorize configurations first, then inject object if necessary
@@ -260,19 +616,21 @@ func (f *ConfigurableFactory) Instantiate(configuration interface{}) (err error)
 
 // appProfilesActive getter
 func (f *ConfigurableFactory) appProfilesActive() string {
-	if f.systemConfig == nil {
+	systemConfig := f.SystemConfiguration()
+	if systemConfig == nil {
 		return os.Getenv(appProfilesActive)
 	}
-	return f.systemConfig.App.Profiles.Active
+	return systemConfig.App.Profiles.Active
 }
 
 // build
 func (f *ConfigurableFactory) build(cfgContainer cmap.ConcurrentMap) {
 	isTestRunning := gotest.IsRunning()
+	systemConfig := f.SystemConfiguration()
 	for item := range cfgContainer.IterBuffered() {
 		name, configType := item.Key, item.Val
 		// TODO: should check if profiles is enabled str.InSlice(name, sysconf.App.Profiles.Include)
-		if !isTestRunning && f.systemConfig != nil && !str.InSlice(name, f.systemConfig.App.Profiles.Include) {
+		if !isTestRunning && systemConfig != nil && !str.InSlice(name, systemConfig.App.Profiles.Include) {
 			continue
 		}
 		log.Infof("Auto configure %v starter", name)
@@ -289,12 +647,13 @@ func (f *ConfigurableFactory) build(cfgContainer cmap.ConcurrentMap) {
 		if cf == nil {
 			log.Warnf("failed to build %v configuration with error %v", name, err)
 		} else {
-			// replace references and environment variables
-			if f.systemConfig != nil {
-				replacer.Replace(cf, f.systemConfig)
-			}
+			// replace references and environment variables, the system configuration is looked up first
 			inject.IntoObject(cf)
-			replacer.Replace(cf, cf)
+			if systemConfig != nil {
+				replacer.Replace(cf, systemConfig, cf)
+			} else {
+				replacer.Replace(cf, cf)
+			}
 
 			// instantiation
 			if err == nil {
@@ -304,7 +663,9 @@ func (f *ConfigurableFactory) build(cfgContainer cmap.ConcurrentMap) {
 				if _, ok := f.configurations.Get(name); ok {
 					log.Fatalf("[factory] configuration name %v is already taken", name)
 				}
-				f.configurations.Set(name, cf)
+				f.mu.Lock()
+				f.setConfiguration(name, cf)
+				f.mu.Unlock()
 			}
 		}
 	}
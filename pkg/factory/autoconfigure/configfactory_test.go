@@ -16,16 +16,20 @@ package autoconfigure_test
 
 import (
 	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/factory"
 	"github.com/hidevopsio/hiboot/pkg/factory/autoconfigure"
 	"github.com/hidevopsio/hiboot/pkg/factory/instantiate"
 	"github.com/hidevopsio/hiboot/pkg/inject"
 	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/system"
 	"github.com/hidevopsio/hiboot/pkg/utils/cmap"
 	"github.com/hidevopsio/hiboot/pkg/utils/io"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 type FakeProperties struct {
@@ -261,3 +265,208 @@ func TestConfigurableFactory(t *testing.T) {
 		assert.Equal(t, "foo", fooConfig.FakeProperties.Name)
 	})
 }
+
+func TestConfigLocation(t *testing.T) {
+	workDir := io.GetWorkDir()
+	f := new(autoconfigure.ConfigurableFactory)
+
+	t.Run("should get the default config location", func(t *testing.T) {
+		paths, name, file := f.ConfigLocation()
+		assert.Equal(t, []string{filepath.Join(workDir, "config")}, paths)
+		assert.Equal(t, "application", name)
+		assert.Equal(t, "", file)
+
+		configFiles, ok := autoconfigure.LocalConfigFiles(nil, "")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, filepath.Join("config", "application.yaml"), configFiles[0])
+		assert.Contains(t, configFiles, filepath.Join("config", "application.yml"))
+		assert.Contains(t, configFiles, filepath.Join("config", "application.json"))
+		assert.Contains(t, configFiles, filepath.Join("config", "application.toml"))
+	})
+
+	t.Run("should get the config location from environment variables", func(t *testing.T) {
+		os.Setenv("APP_CONFIG_PATH", "/etc/hiboot, conf")
+		os.Setenv("APP_CONFIG_NAME", "app")
+		os.Setenv("APP_CONFIG_FILE", "/etc/config/app.yml")
+		defer func() {
+			os.Unsetenv("APP_CONFIG_PATH")
+			os.Unsetenv("APP_CONFIG_NAME")
+			os.Unsetenv("APP_CONFIG_FILE")
+		}()
+		paths, name, file := f.ConfigLocation()
+		assert.Equal(t, []string{"/etc/hiboot", filepath.Join(workDir, "conf")}, paths)
+		assert.Equal(t, "app", name)
+		assert.Equal(t, "/etc/config/app.yml", file)
+
+		_, ok := autoconfigure.LocalConfigFiles(nil, "")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should get the config location from command line flags", func(t *testing.T) {
+		args := os.Args
+		os.Args = append([]string{args[0]}, "--config-path=conf", "--config-name", "app")
+		defer func() { os.Args = args }()
+		paths, name, _ := f.ConfigLocation()
+		assert.Equal(t, []string{filepath.Join(workDir, "conf")}, paths)
+		assert.Equal(t, "app", name)
+
+		configFiles, ok := autoconfigure.LocalConfigFiles(nil, "")
+		assert.Equal(t, true, ok)
+		assert.Contains(t, configFiles, filepath.Join("conf", "app.yml"))
+	})
+
+	t.Run("should get the config location that is set explicitly", func(t *testing.T) {
+		os.Setenv("APP_CONFIG_NAME", "app")
+		defer os.Unsetenv("APP_CONFIG_NAME")
+		f.SetConfigLocation([]string{"/etc/foo,/etc/bar"}, "foo", "foo.yml")
+		paths, name, file := f.ConfigLocation()
+		assert.Equal(t, []string{"/etc/foo", "/etc/bar"}, paths)
+		assert.Equal(t, "foo", name)
+		assert.Equal(t, filepath.Join(workDir, "foo.yml"), file)
+
+		configFiles, ok := autoconfigure.LocalConfigFiles([]string{"conf/foo, /etc/bar"}, "foo")
+		assert.Equal(t, true, ok)
+		assert.Contains(t, configFiles, filepath.Join("conf", "foo", "foo.yml"))
+
+		_, ok = autoconfigure.LocalConfigFiles([]string{"/etc/foo"}, "foo")
+		assert.Equal(t, false, ok)
+	})
+}
+
+type fakePropertySource struct {
+	props map[string]interface{}
+}
+
+func (s *fakePropertySource) Name() string {
+	return "fake"
+}
+
+func (s *fakePropertySource) Properties() (map[string]interface{}, error) {
+	return s.props, nil
+}
+
+type changingPropertySource struct {
+	mu    sync.Mutex
+	props map[string]interface{}
+	reads int
+}
+
+func (s *changingPropertySource) Name() string {
+	return "changing"
+}
+
+func (s *changingPropertySource) Properties() (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	props := make(map[string]interface{})
+	for k, v := range s.props {
+		props[k] = v
+	}
+	return props, nil
+}
+
+func (s *changingPropertySource) set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.props[key] = value
+}
+
+func TestConfigurableFactoryWithPropertySource(t *testing.T) {
+	io.ChangeWorkDir(os.TempDir())
+	f := new(autoconfigure.ConfigurableFactory)
+	f.InstantiateFactory = new(instantiate.InstantiateFactory)
+	f.InstantiateFactory.Initialize(cmap.New())
+	f.Initialize(cmap.New())
+	f.AddPropertySource(&fakePropertySource{props: map[string]interface{}{
+		"app.name":    "remote-app",
+		"app.project": "${app.name}-project",
+	}})
+
+	t.Run("should override the local configuration by property source", func(t *testing.T) {
+		sc, err := f.BuildSystemConfig()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "remote-app", sc.App.Name)
+		assert.Equal(t, "remote-app-project", sc.App.Project)
+	})
+
+	t.Run("should stop refreshing property sources more than once", func(t *testing.T) {
+		f.Destroy()
+		f.Destroy()
+	})
+}
+
+// nicknameService reads the nickname of the refreshed configuration
+type nicknameService struct {
+	mu       sync.Mutex
+	nickname string
+}
+
+func (s *nicknameService) OnRefresh(f factory.ConfigurableFactory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nickname = f.Configuration("fake").(*FakeConfiguration).FakeProperties.Nickname
+}
+
+func (s *nicknameService) Nickname() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nickname
+}
+
+func TestConfigurableFactoryRefreshPropertySource(t *testing.T) {
+	io.ChangeWorkDir(os.TempDir())
+	f := new(autoconfigure.ConfigurableFactory)
+	f.InstantiateFactory = new(instantiate.InstantiateFactory)
+	f.InstantiateFactory.Initialize(cmap.New())
+	f.Initialize(cmap.New())
+	defer f.Destroy()
+	source := &changingPropertySource{props: map[string]interface{}{
+		"sources.interval": "10ms",
+		"app.name":         "remote-app",
+		"app.project":      "\\${app.name}",
+	}}
+	f.AddPropertySource(source)
+
+	sc, err := f.BuildSystemConfig()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "${app.name}", sc.App.Project)
+
+	t.Run("should read the property source only once", func(t *testing.T) {
+		f.Build([][]interface{}{{new(FakeConfiguration)}})
+		source.mu.Lock()
+		defer source.mu.Unlock()
+		assert.Equal(t, 1, source.reads)
+	})
+
+	service := new(nicknameService)
+	f.SetInstance("nicknameService", service)
+
+	t.Run("should replace the configuration instead of modifying it", func(t *testing.T) {
+		fake := f.Configuration("fake").(*FakeConfiguration)
+		nickname := fake.FakeProperties.Nickname
+		source.set("fake.nickname", "refreshed")
+		source.set("app.name", "refreshed-app")
+		var refreshed *system.Configuration
+		for i := 0; i < 100; i++ {
+			refreshed = f.SystemConfiguration()
+			if refreshed.App.Name == "refreshed-app" {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, "refreshed-app", refreshed.App.Name)
+		assert.Equal(t, "${app.name}", refreshed.App.Project)
+		assert.Equal(t, refreshed, f.Configuration(autoconfigure.System))
+		assert.Equal(t, "remote-app", sc.App.Name)
+		assert.Equal(t, "refreshed", f.Configuration("fake").(*FakeConfiguration).FakeProperties.Nickname)
+		assert.Equal(t, nickname, fake.FakeProperties.Nickname)
+	})
+
+	t.Run("should notify the refresh listeners", func(t *testing.T) {
+		for i := 0; i < 100 && service.Nickname() != "refreshed"; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, "refreshed", service.Nickname())
+	})
+}
@@ -31,3 +31,10 @@ type ConfigurableFactory interface {
 	SystemConfiguration() *system.Configuration
 	Configuration(name string) interface{}
 }
+
+// RefreshListener is implemented by the instance that is notified after the configurations are refreshed by the
+// property sources. The configurations are replaced instead of modified on refresh, so the instance that is created
+// from them keeps the old values unless it looks them up again from the factory, e.g. factory.Configuration("foo")
+type RefreshListener interface {
+	OnRefresh(factory ConfigurableFactory)
+}
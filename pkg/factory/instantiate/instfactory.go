@@ -25,6 +25,7 @@ import (
 	"github.com/hidevopsio/hiboot/pkg/utils/str"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 var (
@@ -38,6 +39,9 @@ var (
 // InstantiateFactory is the factory that responsible for object instantiation
 type InstantiateFactory struct {
 	instanceMap cmap.ConcurrentMap
+	// names are the names of the instances in the order of creation
+	mu    sync.Mutex
+	names []string
 }
 
 // Initialize init the factory
@@ -142,9 +146,20 @@ func (f *InstantiateFactory) SetInstance(name string, instance interface{}) (err
 	}
 
 	f.instanceMap.Set(name, instance)
+	f.mu.Lock()
+	f.names = append(f.names, name)
+	f.mu.Unlock()
 	return
 }
 
+// Names returns the names of the instances in the order of creation, the dependencies of an instance that is
+// created by the method of configuration are created before it
+func (f *InstantiateFactory) Names() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.names...)
+}
+
 // GetInstance get instance by name
 func (f *InstantiateFactory) GetInstance(name string) (inst interface{}) {
 	if !f.Initialized() {
@@ -15,6 +15,7 @@
 package inject
 
 import (
+	"fmt"
 	"github.com/hidevopsio/hiboot/pkg/utils/str"
 	"reflect"
 )
@@ -46,7 +47,8 @@ func (t *defaultTag) Decode(object reflect.Value, field reflect.StructField, tag
 		}
 
 		if needConvert {
-			retVal = str.Convert(tag, kind)
+			// the tag may contain placeholders, e.g. ${random.int(8000,9000)}
+			retVal = str.Convert(fmt.Sprintf("%v", t.replaceReferences(tag)), kind)
 		}
 	}
 	return retVal
@@ -19,7 +19,7 @@ import (
 	"github.com/hidevopsio/hiboot/pkg/app/web"
 )
 
-//This example shows that the dependency is injected through the constructor
+// This example shows that the dependency is injected through the constructor
 func Example() {
 }
 
@@ -63,6 +63,11 @@ func newHelloController(helloService HelloService) *helloController {
 	}
 }
 
+// RequestMapping names the argument of Get, which is bound from query string, header or cookie
+func (c *helloController) RequestMapping() map[string]string {
+	return map[string]string{"Get": "/?name"}
+}
+
 // Get /
 // The first word of method name is the http method GET
 func (c *helloController) Get(name string) string {
@@ -420,6 +420,23 @@ func TestInject(t *testing.T) {
 		assert.NotEqual(t, a.TestName, b.TestName)
 	})
 
+	t.Run("should inject generated values", func(t *testing.T) {
+		a := &struct {
+			ID      string `value:"${random.uuid}"`
+			Port    int    `value:"${random.int(8000,9000)}"`
+			Name    string `default:"${app.name}-${random.int(10)}"`
+			Escaped string `value:"\\${app.name}"`
+		}{}
+		err := inject.DefaultValue(a)
+		assert.Equal(t, nil, err)
+		err = inject.IntoObject(a)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 36, len(a.ID))
+		assert.Equal(t, true, a.Port >= 8000 && a.Port < 9000)
+		assert.Equal(t, appName+"-", a.Name[:len(appName)+1])
+		assert.Equal(t, "${app.name}", a.Escaped)
+	})
+
 	t.Run("should deduplicate tag", func(t *testing.T) {
 		inject.AddTag(new(testTag))
 		inject.AddTag(nil)
@@ -44,34 +44,25 @@ func (t *BaseTag) Init(systemConfig *system.Configuration, configurations cmap.C
 	t.configurations = configurations
 }
 
-// TODO move to replacer ?
+// replaceReferences replace the references of configurations, env variables and built-in generators
 func (t *BaseTag) replaceReferences(val string) interface{} {
-	var retVal interface{}
-	retVal = val
-
 	matches := replacer.GetMatches(val)
-	if len(matches) != 0 {
-		for _, m := range matches {
-			//log.Debug(m[1])
-			// default value
+	if len(matches) == 0 {
+		return val
+	}
 
-			vars := strings.SplitN(m[1], ".", -1)
-			configName := vars[0]
-			// trying to find config
-			config, ok := t.configurations.Get(configName)
-			sysConf, err := replacer.GetReferenceValue(t.systemConfig, configName)
-			if !ok && err == nil && sysConf.IsValid() {
-				config = t.systemConfig
-			}
-			if config != nil {
-				retVal = replacer.ReplaceStringVariables(val, config)
-				if retVal != val {
-					break
-				}
-			}
+	var roots []interface{}
+	for _, m := range matches {
+		vars := strings.SplitN(m[1], ".", -1)
+		configName := vars[0]
+		// trying to find config
+		if config, ok := t.configurations.Get(configName); ok {
+			roots = append(roots, config)
+		} else if sysConf, err := replacer.GetReferenceValue(t.systemConfig, configName); err == nil && sysConf.IsValid() {
+			roots = append(roots, t.systemConfig)
 		}
 	}
-	return retVal
+	return replacer.ReplaceStringVariables(val, roots...)
 }
 
 func (t *BaseTag) ParseProperties(tag string) cmap.ConcurrentMap {
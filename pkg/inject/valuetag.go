@@ -15,6 +15,7 @@
 package inject
 
 import (
+	"fmt"
 	"github.com/hidevopsio/hiboot/pkg/utils/str"
 	"reflect"
 )
@@ -50,7 +51,8 @@ func (t *valueTag) Decode(object reflect.Value, field reflect.StructField, tag s
 		}
 
 		if needConvert {
-			retVal = str.Convert(tag, kind)
+			// the tag may contain placeholders, e.g. ${random.int(8000,9000)}
+			retVal = str.Convert(fmt.Sprintf("%v", t.replaceReferences(tag)), kind)
 		}
 	}
 	return retVal
@@ -0,0 +1,115 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"github.com/kataras/golog"
+)
+
+// requestIDKey is the key of the request id in context
+type requestIDKey struct{}
+
+// NewContext returns the context that carries the request id, e.g. the context of gRPC client calls
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request id carried by ctx, or empty string if there is none
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Logger writes the request id at the beginning of every log line, e.g. [1234567890] message
+type Logger struct {
+	prefix string
+}
+
+// WithRequestID returns the logger of the request id
+func WithRequestID(requestID string) *Logger {
+	l := new(Logger)
+	if requestID != "" {
+		l.prefix = fmt.Sprintf("[%v] ", requestID)
+	}
+	return l
+}
+
+// WithContext returns the logger of the request id carried by ctx
+func WithContext(ctx context.Context) *Logger {
+	return WithRequestID(RequestID(ctx))
+}
+
+func (l *Logger) args(v []interface{}) []interface{} {
+	if l.prefix == "" {
+		return v
+	}
+	return append([]interface{}{l.prefix}, v...)
+}
+
+// format returns the format and args that the prefix is given as an argument, so that the request id from client,
+// e.g. %s%d, is never interpreted as verbs
+func (l *Logger) format(format string, args []interface{}) (string, []interface{}) {
+	if l.prefix == "" {
+		return format, args
+	}
+	return "%s" + format, append([]interface{}{l.prefix}, args...)
+}
+
+// Error will print only when logger's Level is error, warn, info or debug.
+func (l *Logger) Error(v ...interface{}) {
+	withCaller(golog.Error, l.args(v)...)
+}
+
+// Errorf will print only when logger's Level is error, warn, info or debug.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	f, a := l.format(format, args)
+	withCallerf(golog.Errorf, f, a...)
+}
+
+// Warn will print when logger's Level is warn, info or debug.
+func (l *Logger) Warn(v ...interface{}) {
+	golog.Warn(l.args(v)...)
+}
+
+// Warnf will print when logger's Level is warn, info or debug.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	golog.Warnf(l.format(format, args))
+}
+
+// Info will print when logger's Level is info or debug.
+func (l *Logger) Info(v ...interface{}) {
+	golog.Info(l.args(v)...)
+}
+
+// Infof will print when logger's Level is info or debug.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	golog.Infof(l.format(format, args))
+}
+
+// Debug will print when logger's Level is debug.
+func (l *Logger) Debug(v ...interface{}) {
+	withCaller(golog.Debug, l.args(v)...)
+}
+
+// Debugf will print when logger's Level is debug.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	f, a := l.format(format, args)
+	withCallerf(golog.Debugf, f, a...)
+}
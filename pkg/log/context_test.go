@@ -0,0 +1,66 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Run("should carry request id in context", func(t *testing.T) {
+		ctx := NewContext(context.Background(), "123")
+		assert.Equal(t, "123", RequestID(ctx))
+	})
+
+	t.Run("should return empty request id", func(t *testing.T) {
+		assert.Equal(t, "", RequestID(context.Background()))
+		assert.Equal(t, "", RequestID(nil))
+	})
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("should prefix log lines with request id", func(t *testing.T) {
+		l := WithContext(NewContext(context.Background(), "123"))
+		assert.Equal(t, "[123] ", l.prefix)
+		assert.Equal(t, []interface{}{"[123] ", "hello"}, l.args([]interface{}{"hello"}))
+	})
+
+	t.Run("should not prefix log lines without request id", func(t *testing.T) {
+		l := WithRequestID("")
+		assert.Equal(t, []interface{}{"hello"}, l.args([]interface{}{"hello"}))
+	})
+
+	t.Run("should not interpret the request id as verbs", func(t *testing.T) {
+		l := WithRequestID("%s%s%d")
+		format, args := l.format("hello %v", []interface{}{1})
+		assert.Equal(t, "[%s%s%d] hello 1", fmt.Sprintf(format, args...))
+		l.Infof("hello %v", 1)
+	})
+
+	t.Run("should write log lines", func(t *testing.T) {
+		l := WithRequestID("123")
+		l.Debug("debug")
+		l.Debugf("debug %v", 1)
+		l.Info("info")
+		l.Infof("info %v", 1)
+		l.Warn("warn")
+		l.Warnf("warn %v", 1)
+		l.Error("error")
+		l.Errorf("error %v", 1)
+	})
+}
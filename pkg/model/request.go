@@ -15,10 +15,13 @@
 package model
 
 var (
-	RequestTypeBody   = "RequestBody"
-	RequestTypeParams = "RequestParams"
-	RequestTypeForm   = "RequestForm"
-	Context           = "Context"
+	RequestTypeBody      = "RequestBody"
+	RequestTypeParams    = "RequestParams"
+	RequestTypeForm      = "RequestForm"
+	RequestTypeHeader    = "RequestHeader"
+	RequestTypeCookie    = "RequestCookie"
+	RequestTypeMultipart = "RequestMultipart"
+	Context              = "Context"
 )
 
 type RequestBody struct{}
@@ -26,3 +29,14 @@ type RequestBody struct{}
 type RequestForm struct{}
 
 type RequestParams struct{}
+
+// RequestHeader binds the request headers into the fields, the header name is the field name or given by tag header
+type RequestHeader struct{}
+
+// RequestCookie binds the request cookies into the fields, the cookie name is the field name or given by tag cookie
+type RequestCookie struct{}
+
+// RequestMultipart binds the form values and the uploaded files of multipart request into the fields, the part name
+// is the field name or given by tag form, the files are bound into the fields of type *multipart.FileHeader
+// or []*multipart.FileHeader
+type RequestMultipart struct{}
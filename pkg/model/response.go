@@ -23,10 +23,17 @@ type Response interface {
 	GetData() interface{}
 }
 
+// ErrorResponse is implemented by the response that carries the error code
+type ErrorResponse interface {
+	SetErrorCode(code string)
+	GetErrorCode() string
+}
+
 type BaseResponse struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Code      int         `json:"code"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data"`
 }
 
 func (r *BaseResponse) SetCode(code int) {
@@ -52,3 +59,59 @@ func (r *BaseResponse) SetData(data interface{}) {
 func (r *BaseResponse) GetData() interface{} {
 	return r.Data
 }
+
+func (r *BaseResponse) SetErrorCode(code string) {
+	r.ErrorCode = code
+}
+
+func (r *BaseResponse) GetErrorCode() string {
+	return r.ErrorCode
+}
+
+// FieldError is the validation error of the request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Event is the server-sent event that is sent by the channel returned from the controller method, e.g. <-chan Event,
+// the data is sent as it is if it is a string, or in json otherwise. The producer of the channel must select on the
+// done channel of the request context to stop sending and close the channel once the client disconnects, the values
+// that are sent after the disconnection are discarded for up to a minute, then the producer that is still blocked on
+// sending is leaked. The line breaks in ID and Name are removed
+//
+//	done := c.Ctx.Request().Context().Done()
+//	go func() {
+//		defer close(events)
+//		for {
+//			select {
+//			case <-done:
+//				return
+//			case events <- Event{Data: time.Now()}:
+//			}
+//		}
+//	}()
+type Event struct {
+	// ID is the event id that is sent back by the client in Last-Event-ID header on reconnection
+	ID string
+	// Name is the event type, the client receives it by the message listener if it is empty
+	Name string
+	// Data is the event data
+	Data interface{}
+	// Retry is the reconnection time in milliseconds
+	Retry int
+}
+
+// View is the view model that is rendered by the template engine of the view starter, e.g. the controller method
+// returns model.View{Name: "user.html", Data: user}
+type View struct {
+	// Name is the file name of the template relative to the template directory, e.g. user/profile.html
+	Name string
+	// Data is the binding data of the template, the language lang is added to it if it is a map, the i18n
+	// function tr of the template engine translates to the language, e.g. {{tr .lang "welcome" .name}}
+	Data interface{}
+	// Layout overrides the layout of the view starter, e.g. layouts/admin.html
+	Layout string
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/factory/autoconfigure"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/starter/actuator/controller"
+)
+
+const name = "actuator"
+
+type configuration struct {
+	app.Configuration
+	Properties Properties `mapstructure:"actuator"`
+}
+
+type postProcessor struct {
+	applicationContext app.ApplicationContext
+}
+
+func init() {
+	app.AutoConfiguration(new(configuration))
+	app.RegisterPostProcessor(new(postProcessor))
+}
+
+func (p *postProcessor) Init(applicationContext app.ApplicationContext) {
+	p.applicationContext = applicationContext
+}
+
+func (p *postProcessor) BeforeInitialization(factory interface{}) {
+}
+
+// AfterInitialization registers the config endpoint if it is enabled
+func (p *postProcessor) AfterInitialization(factory interface{}) {
+	f, ok := factory.(*autoconfigure.ConfigurableFactory)
+	if !ok {
+		return
+	}
+	c, ok := f.Configuration(name).(*configuration)
+	if !ok || !c.Properties.Config.Enabled {
+		return
+	}
+	err := p.applicationContext.RegisterController(new(controller.ConfigEndpoint))
+	if err != nil {
+		log.Warnf("[actuator] %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator_test
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app/web"
+	_ "github.com/hidevopsio/hiboot/pkg/starter/actuator"
+	"net/http"
+	"testing"
+)
+
+func TestConfigEndpoint(t *testing.T) {
+	testApp := web.NewTestApplication(t)
+
+	t.Run("should get the effective configuration", func(t *testing.T) {
+		testApp.Get("/config").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("app.name", "actuator-starter")
+	})
+
+	t.Run("should get the configuration metadata", func(t *testing.T) {
+		testApp.Get("/config/metadata").
+			Expect().Status(http.StatusOK).
+			JSON().Array().NotEmpty()
+	})
+}
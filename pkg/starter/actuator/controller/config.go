@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app/web"
+	"github.com/hidevopsio/hiboot/pkg/factory/autoconfigure"
+)
+
+// ConfigEndpoint is the controller interface of the config endpoints, they are registered by the actuator
+// starter only if actuator.config.enabled is true
+type ConfigEndpoint interface {
+}
+
+type configController struct {
+	ConfigEndpoint
+	web.Controller
+	configurableFactory *autoconfigure.ConfigurableFactory
+}
+
+func init() {
+	web.RestController(new(configController))
+}
+
+func (c *configController) Init(configurableFactory *autoconfigure.ConfigurableFactory) {
+	c.configurableFactory = configurableFactory
+}
+
+// Get returns the effective configuration, the secrets are masked
+func (c *configController) Get() {
+	c.Ctx.JSON(c.configurableFactory.ConfigDump())
+}
+
+// GetMetadata returns the metadata of all configuration properties
+func (c *configController) GetMetadata() {
+	c.Ctx.JSON(c.configurableFactory.Metadata())
+}
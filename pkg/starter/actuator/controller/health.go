@@ -1,6 +1,10 @@
 package controller
 
-import "github.com/hidevopsio/hiboot/pkg/app/web"
+import (
+	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/app/web"
+	"net/http"
+)
 
 // Health is the health check struct
 type Health struct {
@@ -23,3 +27,16 @@ func (c *healthController) Get() {
 	}
 	c.Ctx.JSON(health)
 }
+
+// GET /health/readiness
+func (c *healthController) GetReadiness() {
+	health := Health{
+		Status: "UP",
+	}
+	// the readiness is down during graceful shutdown
+	if !app.IsReady() {
+		health.Status = "OUT_OF_SERVICE"
+		c.Ctx.StatusCode(http.StatusServiceUnavailable)
+	}
+	c.Ctx.JSON(health)
+}
@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"github.com/hidevopsio/hiboot/pkg/app"
 	"github.com/hidevopsio/hiboot/pkg/app/web"
 	_ "github.com/hidevopsio/hiboot/pkg/starter/actuator/controller"
 	"net/http"
@@ -12,3 +13,21 @@ func TestHealthController(t *testing.T) {
 		Get("/health").
 		Expect().Status(http.StatusOK)
 }
+
+func TestReadiness(t *testing.T) {
+	testApp := web.NewTestApplication(t)
+
+	t.Run("should be ready", func(t *testing.T) {
+		testApp.Get("/health/readiness").
+			Expect().Status(http.StatusOK).
+			JSON().Object().ValueEqual("status", "UP")
+	})
+
+	t.Run("should be out of service during shutdown", func(t *testing.T) {
+		app.SetReady(false)
+		defer app.SetReady(true)
+		testApp.Get("/health/readiness").
+			Expect().Status(http.StatusServiceUnavailable).
+			JSON().Object().ValueEqual("status", "OUT_OF_SERVICE")
+	})
+}
@@ -0,0 +1,28 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actuator
+
+// Endpoint is the properties of an actuator endpoint
+type Endpoint struct {
+	// Enabled exposes the endpoint if it is true
+	Enabled bool `json:"enabled"`
+}
+
+// Properties the properties of actuator
+type Properties struct {
+	// Config is the endpoint /config that exposes the effective configuration and its metadata, the secrets
+	// are masked but it is disabled by default, as the configuration may reveal the internals of the application
+	Config Endpoint `json:"config"`
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/app/web"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/kataras/iris/context"
+)
+
+type configuration struct {
+	app.PreConfiguration
+	Properties         Properties `mapstructure:"cors"`
+	applicationContext app.ApplicationContext
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+func init() {
+	app.AutoConfiguration(newConfiguration)
+}
+
+// CorsHandler config cors handler, the application fails to start if the policy is insecure
+func (c *configuration) CorsHandler() context.Handler {
+	cors, err := newCors(&c.Properties)
+	if err != nil {
+		log.Fatal(err)
+	}
+	corsHandler := func(ctx context.Context) {
+		r := ctx.Request()
+		if status := cors.check(r.Method, r.URL.Path, r.Header, ctx.ResponseWriter().Header()); status != 0 {
+			ctx.StatusCode(status)
+			ctx.StopExecution()
+			return
+		}
+		ctx.Next()
+	}
+
+	c.applicationContext.Use(corsHandler)
+	// the preflight requests are routed to the paths of controllers, so that they are handled by corsHandler
+	if responder, ok := c.applicationContext.(web.OptionsResponder); ok {
+		responder.RespondOptions()
+	}
+
+	return corsHandler
+}
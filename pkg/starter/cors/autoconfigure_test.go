@@ -0,0 +1,57 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app/fake"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration(t *testing.T) {
+	c := newConfiguration(new(fake.ApplicationContext))
+
+	t.Run("should get handler", func(t *testing.T) {
+		c.Properties = Properties{
+			Policy: Policy{
+				AllowedOrigins: []string{"*"},
+				AllowedMethods: []string{"GET", "POST"},
+			},
+		}
+		ch := c.CorsHandler()
+		assert.Equal(t, reflect.Func, reflect.TypeOf(ch).Kind())
+	})
+}
+
+type optionsResponder struct {
+	fake.ApplicationContext
+	respondOptions bool
+}
+
+func (a *optionsResponder) RespondOptions() {
+	a.respondOptions = true
+}
+
+func TestRespondOptions(t *testing.T) {
+	applicationContext := new(optionsResponder)
+	c := newConfiguration(applicationContext)
+	c.Properties = Properties{Policy: Policy{AllowedOrigins: []string{"*"}}}
+
+	t.Run("should respond to the OPTIONS requests of controllers", func(t *testing.T) {
+		c.CorsHandler()
+		assert.Equal(t, true, applicationContext.respondOptions)
+	})
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/hidevopsio/hiboot/pkg/utils/str"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerOrigin           = "Origin"
+	headerVary             = "Vary"
+	headerRequestMethod    = "Access-Control-Request-Method"
+	headerRequestHeaders   = "Access-Control-Request-Headers"
+	headerAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAllowCredentials = "Access-Control-Allow-Credentials"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
+	headerMaxAge           = "Access-Control-Max-Age"
+
+	wildcard = "*"
+)
+
+// policy is the normalized Policy of the path
+type policy struct {
+	path             string
+	allowedOrigins   []string
+	allowedMethods   []string
+	allowedHeaders   []string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           int
+}
+
+// cors checks the cross-origin requests by the policies
+type cors struct {
+	defaultPolicy *policy
+	// paths are sorted by the length of path in descending order
+	paths []*policy
+}
+
+func newPolicy(path string, p Policy) *policy {
+	np := &policy{
+		path:             path,
+		allowedMethods:   upper(p.AllowedMethods),
+		allowedHeaders:   canonical(p.AllowedHeaders),
+		exposedHeaders:   strings.Join(canonical(p.ExposedHeaders), ", "),
+		allowCredentials: p.AllowCredentials,
+		maxAge:           p.MaxAge,
+	}
+	for _, o := range p.AllowedOrigins {
+		np.allowedOrigins = append(np.allowedOrigins, strings.ToLower(strings.TrimSpace(o)))
+	}
+	return np
+}
+
+// newCors creates the cors by the properties, the wildcard origin is rejected if the credentials are allowed,
+// as any origin would be allowed to make the requests with the credentials of the user
+func newCors(properties *Properties) (*cors, error) {
+	c := &cors{defaultPolicy: newPolicy("", properties.Policy)}
+	for _, pp := range properties.Paths {
+		p := properties.Policy
+		if pp.AllowedOrigins != nil {
+			p.AllowedOrigins = pp.AllowedOrigins
+		}
+		if pp.AllowedMethods != nil {
+			p.AllowedMethods = pp.AllowedMethods
+		}
+		if pp.AllowedHeaders != nil {
+			p.AllowedHeaders = pp.AllowedHeaders
+		}
+		if pp.ExposedHeaders != nil {
+			p.ExposedHeaders = pp.ExposedHeaders
+		}
+		if pp.AllowCredentials != nil {
+			p.AllowCredentials = *pp.AllowCredentials
+		}
+		if pp.MaxAge != nil {
+			p.MaxAge = *pp.MaxAge
+		}
+		c.paths = append(c.paths, newPolicy(pp.Path, p))
+	}
+	sort.SliceStable(c.paths, func(i, j int) bool {
+		return len(c.paths[i].path) > len(c.paths[j].path)
+	})
+	for _, p := range append(c.paths, c.defaultPolicy) {
+		if p.allowCredentials && str.InSlice(wildcard, p.allowedOrigins) {
+			return nil, &system.ErrInvalidCorsPolicy{Path: p.path}
+		}
+	}
+	return c, nil
+}
+
+// policyOf returns the policy of the longest path that matches the request path
+func (c *cors) policyOf(path string) *policy {
+	for _, p := range c.paths {
+		if path == p.path || strings.HasPrefix(path, strings.TrimSuffix(p.path, "/")+"/") {
+			return p
+		}
+	}
+	return c.defaultPolicy
+}
+
+// check sets the CORS headers of the response by the request, it returns the status code that the request
+// should be stopped with, or 0 if the request should be passed to the next handler. The preflight request is
+// always stopped, so it never reaches the authentication middleware such as jwt
+func (c *cors) check(method, path string, header http.Header, respHeader http.Header) (status int) {
+	origin := header.Get(headerOrigin)
+	preflight := method == http.MethodOptions && header.Get(headerRequestMethod) != ""
+	if origin == "" {
+		// not a cross-origin request
+		return
+	}
+
+	p := c.policyOf(path)
+	respHeader.Add(headerVary, headerOrigin)
+	if preflight {
+		respHeader.Add(headerVary, headerRequestMethod)
+		respHeader.Add(headerVary, headerRequestHeaders)
+	}
+	if !p.isOriginAllowed(origin) {
+		if preflight {
+			status = http.StatusForbidden
+		}
+		return
+	}
+
+	if !preflight {
+		respHeader.Set(headerAllowOrigin, p.allowOrigin(origin))
+		if p.allowCredentials {
+			respHeader.Set(headerAllowCredentials, "true")
+		}
+		if p.exposedHeaders != "" {
+			respHeader.Set(headerExposeHeaders, p.exposedHeaders)
+		}
+		return
+	}
+
+	requestMethod := strings.ToUpper(header.Get(headerRequestMethod))
+	requestHeaders := canonical(strings.Split(header.Get(headerRequestHeaders), ","))
+	if !p.isMethodAllowed(requestMethod) || !p.areHeadersAllowed(requestHeaders) {
+		return http.StatusForbidden
+	}
+	respHeader.Set(headerAllowOrigin, p.allowOrigin(origin))
+	respHeader.Set(headerAllowMethods, strings.Join(p.allowedMethods, ", "))
+	if len(requestHeaders) != 0 {
+		respHeader.Set(headerAllowHeaders, strings.Join(requestHeaders, ", "))
+	}
+	if p.allowCredentials {
+		respHeader.Set(headerAllowCredentials, "true")
+	}
+	if p.maxAge > 0 {
+		respHeader.Set(headerMaxAge, strconv.Itoa(p.maxAge))
+	}
+	return http.StatusNoContent
+}
+
+// allowOrigin returns the value of Access-Control-Allow-Origin, the allowed origin is echoed unless all origins
+// are allowed, which never allows the credentials
+func (p *policy) allowOrigin(origin string) string {
+	if str.InSlice(wildcard, p.allowedOrigins) {
+		return wildcard
+	}
+	return origin
+}
+
+func (p *policy) isOriginAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range p.allowedOrigins {
+		if o == wildcard || o == origin {
+			return true
+		}
+		if n := strings.Index(o, wildcard); n >= 0 {
+			prefix, suffix := o[:n], o[n+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *policy) isMethodAllowed(method string) bool {
+	// the preflight request itself is always allowed
+	return method == http.MethodOptions || str.InSlice(method, p.allowedMethods)
+}
+
+func (p *policy) areHeadersAllowed(headers []string) bool {
+	if str.InSlice(wildcard, p.allowedHeaders) {
+		return true
+	}
+	for _, h := range headers {
+		if !str.InSlice(h, p.allowedHeaders) {
+			return false
+		}
+	}
+	return true
+}
+
+// upper returns the trimmed upper case values without empty ones
+func upper(values []string) (result []string) {
+	for _, v := range values {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			result = append(result, v)
+		}
+	}
+	return
+}
+
+// canonical returns the trimmed canonical header names without empty ones
+func canonical(values []string) (result []string) {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			if v != wildcard {
+				v = http.CanonicalHeaderKey(v)
+			}
+			result = append(result, v)
+		}
+	}
+	return
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	yes := true
+	maxAge := 0
+	c, err := newCors(&Properties{
+		Policy: Policy{
+			AllowedOrigins: []string{"https://*.example.com", "http://localhost:3000"},
+			AllowedMethods: []string{"get", "post"},
+			AllowedHeaders: []string{"content-type", "Authorization"},
+			ExposedHeaders: []string{"x-total-count"},
+			MaxAge:         600,
+		},
+		Paths: []PathPolicy{
+			{Path: "/public", AllowedOrigins: []string{"*"}},
+			{Path: "/public/account", AllowedOrigins: []string{"https://example.org"}, AllowCredentials: &yes, MaxAge: &maxAge},
+		},
+	})
+	assert.Equal(t, nil, err)
+
+	check := func(method, path string, header http.Header) (int, http.Header) {
+		respHeader := make(http.Header)
+		status := c.check(method, path, header, respHeader)
+		return status, respHeader
+	}
+
+	t.Run("should pass the same-origin request", func(t *testing.T) {
+		status, h := check(http.MethodGet, "/foo", http.Header{})
+		assert.Equal(t, 0, status)
+		assert.Equal(t, 0, len(h))
+	})
+
+	t.Run("should set allow origin for the allowed origin", func(t *testing.T) {
+		status, h := check(http.MethodGet, "/foo", http.Header{"Origin": {"https://app.example.com"}})
+		assert.Equal(t, 0, status)
+		assert.Equal(t, "https://app.example.com", h.Get(headerAllowOrigin))
+		assert.Equal(t, "X-Total-Count", h.Get(headerExposeHeaders))
+		assert.Equal(t, "", h.Get(headerAllowCredentials))
+		assert.Equal(t, []string{"Origin"}, h[headerVary])
+	})
+
+	t.Run("should not set allow origin for the disallowed origin", func(t *testing.T) {
+		status, h := check(http.MethodGet, "/foo", http.Header{"Origin": {"https://example.org"}})
+		assert.Equal(t, 0, status)
+		assert.Equal(t, "", h.Get(headerAllowOrigin))
+	})
+
+	t.Run("should respond the preflight request", func(t *testing.T) {
+		status, h := check(http.MethodOptions, "/foo", http.Header{
+			"Origin":             {"http://localhost:3000"},
+			headerRequestMethod:  {"POST"},
+			headerRequestHeaders: {"content-type, authorization"},
+		})
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, "http://localhost:3000", h.Get(headerAllowOrigin))
+		assert.Equal(t, "GET, POST", h.Get(headerAllowMethods))
+		assert.Equal(t, "Content-Type, Authorization", h.Get(headerAllowHeaders))
+		assert.Equal(t, "600", h.Get(headerMaxAge))
+	})
+
+	t.Run("should forbid the preflight request of disallowed method", func(t *testing.T) {
+		status, h := check(http.MethodOptions, "/foo", http.Header{
+			"Origin":            {"http://localhost:3000"},
+			headerRequestMethod: {"DELETE"},
+		})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "", h.Get(headerAllowOrigin))
+	})
+
+	t.Run("should forbid the preflight request of disallowed header", func(t *testing.T) {
+		status, _ := check(http.MethodOptions, "/foo", http.Header{
+			"Origin":             {"http://localhost:3000"},
+			headerRequestMethod:  {"GET"},
+			headerRequestHeaders: {"X-Foo"},
+		})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("should forbid the preflight request of disallowed origin", func(t *testing.T) {
+		status, _ := check(http.MethodOptions, "/foo", http.Header{
+			"Origin":            {"https://example.org"},
+			headerRequestMethod: {"GET"},
+		})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("should pass the OPTIONS request that is not preflight", func(t *testing.T) {
+		status, h := check(http.MethodOptions, "/foo", http.Header{"Origin": {"http://localhost:3000"}})
+		assert.Equal(t, 0, status)
+		assert.Equal(t, "http://localhost:3000", h.Get(headerAllowOrigin))
+	})
+
+	t.Run("should override the policy by path", func(t *testing.T) {
+		status, h := check(http.MethodGet, "/public/foo", http.Header{"Origin": {"https://example.org"}})
+		assert.Equal(t, 0, status)
+		assert.Equal(t, "*", h.Get(headerAllowOrigin))
+	})
+
+	t.Run("should not match the path that only shares the prefix", func(t *testing.T) {
+		_, h := check(http.MethodGet, "/publicity", http.Header{"Origin": {"https://example.org"}})
+		assert.Equal(t, "", h.Get(headerAllowOrigin))
+	})
+
+	t.Run("should not allow the origin that is not listed if the credentials are allowed", func(t *testing.T) {
+		status, h := check(http.MethodOptions, "/public/account/1", http.Header{
+			"Origin":            {"https://app.example.com"},
+			headerRequestMethod: {"GET"},
+		})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "", h.Get(headerAllowOrigin))
+		assert.Equal(t, "", h.Get(headerAllowCredentials))
+	})
+
+	t.Run("should echo the origin if the credentials are allowed", func(t *testing.T) {
+		status, h := check(http.MethodOptions, "/public/account/1", http.Header{
+			"Origin":            {"https://example.org"},
+			headerRequestMethod: {"GET"},
+		})
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, "https://example.org", h.Get(headerAllowOrigin))
+		assert.Equal(t, "true", h.Get(headerAllowCredentials))
+		assert.Equal(t, "", h.Get(headerMaxAge))
+	})
+}
+
+func TestNewCorsWithCredentials(t *testing.T) {
+	yes := true
+	t.Run("should reject the wildcard origin with credentials", func(t *testing.T) {
+		_, err := newCors(&Properties{Policy: Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}})
+		assert.Equal(t, &system.ErrInvalidCorsPolicy{}, err)
+	})
+
+	t.Run("should reject the path that allows the credentials of the inherited wildcard origin", func(t *testing.T) {
+		_, err := newCors(&Properties{
+			Policy: Policy{AllowedOrigins: []string{"*"}},
+			Paths:  []PathPolicy{{Path: "/account", AllowCredentials: &yes}},
+		})
+		assert.Equal(t, &system.ErrInvalidCorsPolicy{Path: "/account"}, err)
+	})
+
+	t.Run("should accept the listed origins with credentials", func(t *testing.T) {
+		_, err := newCors(&Properties{Policy: Policy{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}})
+		assert.Equal(t, nil, err)
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+// Policy is the CORS policy of the requests
+type Policy struct {
+	// AllowedOrigins are the origins that are allowed to make cross-domain requests, * allows all origins,
+	// and the origin can contain one wildcard, e.g. https://*.example.com
+	AllowedOrigins []string `json:"allowed_origins" default:"*"`
+	// AllowedMethods are the methods that the client is allowed to use with cross-domain requests
+	AllowedMethods []string `json:"allowed_methods" default:"GET,HEAD,POST,PUT,PATCH,DELETE"`
+	// AllowedHeaders are the non simple headers that the client is allowed to use, * allows all headers
+	AllowedHeaders []string `json:"allowed_headers" default:"Origin,Accept,Accept-Language,Content-Type,Authorization,X-Requested-With"`
+	// ExposedHeaders are the headers that are safe to expose to the client
+	ExposedHeaders []string `json:"exposed_headers"`
+	// AllowCredentials indicates whether the request can include user credentials like cookies or authorization,
+	// the allowed origins must be listed explicitly instead of * if it is true
+	AllowCredentials bool `json:"allow_credentials" default:"false"`
+	// MaxAge is the seconds that the result of preflight request can be cached, 0 means not set
+	MaxAge int `json:"max_age" default:"600"`
+}
+
+// PathPolicy overrides the default policy for the requests whose path has the prefix Path,
+// the properties that are not set are inherited from the default policy
+type PathPolicy struct {
+	// Path is the path prefix of the requests, e.g. /public
+	Path             string   `json:"path"`
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"`
+	AllowCredentials *bool    `json:"allow_credentials"`
+	MaxAge           *int     `json:"max_age"`
+}
+
+// Properties the properties of cors
+type Properties struct {
+	Policy `mapstructure:",squash"`
+	// Paths are the policies of the paths, the longest matched path takes precedence
+	Paths []PathPolicy `json:"paths"`
+}
@@ -24,8 +24,11 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	"net"
+	"time"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type configuration struct {
 	app.Configuration
 	Properties properties `mapstructure:"grpc"`
@@ -101,13 +104,54 @@ func (c *configuration) BuildGrpcClients(cc ClientConnector) {
 	}
 }
 
+// Destroy stops the gRPC server gracefully and closes the client connections on application shutdown
+func (c *configuration) Destroy() {
+	if grpcServer, ok := c.instantiateFactory.GetInstance("grpcServer").(*grpc.Server); ok && grpcServer != nil {
+		timeout, err := time.ParseDuration(c.Properties.Server.ShutdownTimeout)
+		if err != nil || timeout <= 0 {
+			log.Warnf("invalid gRPC shutdown timeout: %v, the default timeout %v is used", c.Properties.Server.ShutdownTimeout, defaultShutdownTimeout)
+			timeout = defaultShutdownTimeout
+		}
+		stopServer(grpcServer, timeout)
+		log.Info("gRPC server is stopped")
+	}
+	closed := make(map[string]bool)
+	for _, cli := range grpcClients {
+		if closed[cli.name] {
+			continue
+		}
+		closed[cli.name] = true
+		if conn, ok := c.instantiateFactory.GetInstance(cli.name).(*grpc.ClientConn); ok && conn != nil {
+			if err := conn.Close(); err != nil {
+				log.Warnf("failed to close gRPC client connection %v: %v", cli.name, err)
+			}
+		}
+	}
+}
+
+// stopServer stops the gRPC server gracefully, it is stopped forcibly if the active RPCs are not completed
+// within the timeout
+func stopServer(grpcServer *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Warnf("gRPC server is not stopped gracefully within %v, it is stopped forcibly", timeout)
+		grpcServer.Stop()
+	}
+}
+
 // GrpcServer create new gRpc Server
 func (c *configuration) GrpcServer() *grpc.Server {
 	// just return if grpc server is not enabled
 	if !c.Properties.Server.Enabled {
 		return nil
 	}
-	return grpc.NewServer()
+	return grpc.NewServer(grpc.UnaryInterceptor(unaryServerInterceptor), grpc.StreamInterceptor(streamServerInterceptor))
 }
 
 // RunGrpcServers create gRPC servers that registered by application
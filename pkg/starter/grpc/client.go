@@ -32,7 +32,9 @@ func (c *clientConnector) Connect(name string, cb interface{}, prop *ClientPrope
 	conn := c.instantiateFactory.GetInstance(name)
 	if conn == nil {
 		// connect to grpc server
-		conn, err = grpc.Dial(address, grpc.WithInsecure())
+		conn, err = grpc.Dial(address, grpc.WithInsecure(),
+			grpc.WithUnaryInterceptor(unaryClientInterceptor),
+			grpc.WithStreamInterceptor(streamClientInterceptor))
 		c.instantiateFactory.SetInstance(name, conn)
 		if err != nil {
 			log.Errorf("failed to connect to grpc server %v", address)
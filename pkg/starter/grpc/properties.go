@@ -20,6 +20,9 @@ type server struct {
 	Host string `json:"host"`
 	// server port, default is 7575
 	Port string `json:"port" default:"7575"`
+	// ShutdownTimeout is the max duration to wait for the active RPCs to complete on shutdown, e.g. 30s,
+	// the server is stopped forcibly after it
+	ShutdownTimeout string `json:"shutdown_timeout" default:"30s"`
 }
 
 type keepAlive struct {
@@ -0,0 +1,79 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDMetadata is the metadata key of the request id, which is X-Request-ID of web requests
+const requestIDMetadata = "x-request-id"
+
+// withRequestID returns the outgoing context that carries the request id of ctx in metadata
+func withRequestID(ctx context.Context) context.Context {
+	if requestID := log.RequestID(ctx); requestID != "" {
+		return metadata.AppendToOutgoingContext(ctx, requestIDMetadata, requestID)
+	}
+	return ctx
+}
+
+// unaryClientInterceptor forwards the request id, e.g. the context of web.Context.RequestContext()
+func unaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(withRequestID(ctx), method, req, reply, cc, opts...)
+}
+
+// streamClientInterceptor forwards the request id on the stream
+func streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(withRequestID(ctx), desc, cc, method, opts...)
+}
+
+// unaryServerInterceptor puts the request id in the incoming metadata into the context of the handler, so that
+// it can be logged by log.WithContext(ctx) and forwarded to other gRPC servers
+func unaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(fromRequestID(ctx), req)
+}
+
+// fromRequestID returns the context that carries the request id in the incoming metadata of ctx
+func fromRequestID(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) != 0 && values[0] != "" {
+			return log.NewContext(ctx, values[0])
+		}
+	}
+	return ctx
+}
+
+// serverStream is the server stream of which the context carries the request id
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context that carries the request id
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// streamServerInterceptor puts the request id in the incoming metadata into the context of the stream
+func streamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: fromRequestID(ss.Context())})
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func TestRequestIDInterceptors(t *testing.T) {
+	t.Run("should forward request id in metadata", func(t *testing.T) {
+		ctx := log.NewContext(context.Background(), "123")
+		var md metadata.MD
+		err := unaryClientInterceptor(ctx, "/foo.Bar/Baz", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"123"}, md.Get(requestIDMetadata))
+	})
+
+	t.Run("should not forward empty request id", func(t *testing.T) {
+		var ok bool
+		_, err := streamClientInterceptor(context.Background(), nil, nil, "/foo.Bar/Baz",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				_, ok = metadata.FromOutgoingContext(ctx)
+				return nil, nil
+			})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("should put request id of incoming metadata into context", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadata, "123"))
+		res, err := unaryServerInterceptor(ctx, nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return log.RequestID(ctx), nil
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "123", res)
+	})
+
+	t.Run("should put request id of incoming metadata into stream context", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadata, "123"))
+		var requestID string
+		err := streamServerInterceptor(nil, &testServerStream{ctx: ctx}, nil, func(srv interface{}, ss grpc.ServerStream) error {
+			requestID = log.RequestID(ss.Context())
+			return nil
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "123", requestID)
+	})
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
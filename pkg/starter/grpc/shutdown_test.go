@@ -0,0 +1,74 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"net"
+	"testing"
+	"time"
+)
+
+// blockingService is the service whose stream blocks until the server is stopped
+func blockingService(called chan struct{}) *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{
+		ServiceName: "test.Blocking",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Block",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				close(called)
+				<-stream.Context().Done()
+				return stream.Context().Err()
+			},
+		}},
+	}
+}
+
+func TestStopServer(t *testing.T) {
+	t.Run("should stop the idle server gracefully", func(t *testing.T) {
+		grpcServer := grpc.NewServer()
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Equal(t, nil, err)
+		go grpcServer.Serve(lis)
+
+		start := time.Now()
+		stopServer(grpcServer, time.Minute)
+		assert.Equal(t, true, time.Since(start) < time.Minute)
+	})
+
+	t.Run("should stop the server forcibly if the active RPCs are not completed within the timeout", func(t *testing.T) {
+		called := make(chan struct{})
+		grpcServer := grpc.NewServer()
+		grpcServer.RegisterService(blockingService(called), struct{}{})
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Equal(t, nil, err)
+		go grpcServer.Serve(lis)
+
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+		assert.Equal(t, nil, err)
+		defer conn.Close()
+		stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, "/test.Blocking/Block")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, stream.CloseSend())
+		<-called
+
+		stopServer(grpcServer, 100*time.Millisecond)
+		assert.NotEqual(t, nil, stream.RecvMsg(new(interface{})))
+	})
+}
@@ -32,7 +32,19 @@ func init() {
 }
 
 func (c *configuration) JwtMiddleware(jtk *jwtToken) *JwtMiddleware {
-	return NewJwtMiddleware(mw.Config{
+	return NewJwtMiddleware(middlewareConfig(jtk))
+}
+
+// JwtWebSocketMiddleware is the middleware of the upgrade requests of websocket controllers, which also reads
+// the token from the query parameter token
+func (c *configuration) JwtWebSocketMiddleware(jtk *jwtToken) *JwtMiddleware {
+	config := middlewareConfig(jtk)
+	config.Extractor = fromAuthHeaderOrUpgradeParameter
+	return NewJwtMiddleware(config)
+}
+
+func middlewareConfig(jtk *jwtToken) mw.Config {
+	return mw.Config{
 		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
 			//log.Debug(token)
 			return jtk.VerifyKey(), nil
@@ -41,7 +53,7 @@ func (c *configuration) JwtMiddleware(jtk *jwtToken) *JwtMiddleware {
 		// If the signing method is not constant the ValidationKeyGetter callback can be used to implement additional checks
 		// Important to avoid security issues described here: https://auth0.com/blog/2015/03/31/critical-vulnerabilities-in-json-web-token-libraries/
 		SigningMethod: jwt.SigningMethodRS256,
-	})
+	}
 }
 
 func (c *configuration) JwtToken() Token {
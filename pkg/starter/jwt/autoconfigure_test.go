@@ -39,4 +39,10 @@ func TestAutoConfigure(t *testing.T) {
 	assert.NotEqual(t, nil, token)
 	mw := config.JwtMiddleware(token.(*jwtToken))
 	assert.NotEqual(t, nil, mw)
+
+	t.Run("should authenticate the upgrade requests of websocket controllers by their own middleware", func(t *testing.T) {
+		wsmw := config.JwtWebSocketMiddleware(token.(*jwtToken))
+		assert.NotEqual(t, mw, wsmw)
+		assert.Equal(t, []string{"jwtWebSocketMiddleware"}, new(WebSocketController).UpgradeMiddleware())
+	})
 }
@@ -29,6 +29,25 @@ type Controller struct {
 	web.Controller
 }
 
+// JwtWebSocketController is the interface that categorizes the websocket controllers whose upgrade requests are
+// authenticated by JWT, it is embedded by WebSocketController
+type JwtWebSocketController interface {
+}
+
+// WebSocketController is the base websocket controller that the upgrade request is authenticated by JWT,
+// the token is given by the Authorization header or the query parameter token, as the browsers can not set
+// the headers of websocket requests
+type WebSocketController struct {
+	JwtWebSocketController
+	web.WebSocketController
+}
+
+// UpgradeMiddleware authenticates the upgrade request by jwtWebSocketMiddleware, it is applied before the middleware
+// that the controller declares by Middleware
+func (c *WebSocketController) UpgradeMiddleware() []string {
+	return []string{"jwtWebSocketMiddleware"}
+}
+
 // ParseToken is an util that parsing JWT token from jwt.MapClaims
 func (c *Controller) ParseToken(claims jwt.MapClaims, prop string) string {
 	return fmt.Sprintf("%v", claims[prop])
@@ -17,10 +17,12 @@ package jwt_test
 import (
 	"fmt"
 	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/hidevopsio/hiboot/pkg/app"
 	"github.com/hidevopsio/hiboot/pkg/app/web"
 	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/hidevopsio/hiboot/pkg/model"
 	"github.com/hidevopsio/hiboot/pkg/starter/jwt"
+	"github.com/kataras/iris/context"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"testing"
@@ -159,3 +161,54 @@ func TestParseToken(t *testing.T) {
 	username := jc.ParseToken(claims, "username")
 	assert.Equal(t, "john", username)
 }
+
+type traceConfiguration struct {
+	app.Configuration
+}
+
+func newTraceConfiguration() *traceConfiguration {
+	return &traceConfiguration{}
+}
+
+func (c *traceConfiguration) TraceMiddleware() context.Handler {
+	return func(ctx context.Context) {
+		ctx.Header("X-Trace", "traced")
+		ctx.Next()
+	}
+}
+
+// chatController declares its own middleware, which does not drop the jwt authentication of the upgrade request
+type chatController struct {
+	jwt.WebSocketController
+}
+
+func (c *chatController) Middleware() map[string][]string {
+	return map[string][]string{"OnConnect": {"traceMiddleware"}}
+}
+
+func (c *chatController) OnMessage(conn *web.Conn, message string) string {
+	return message
+}
+
+func TestWebSocketControllerMiddleware(t *testing.T) {
+	app.AutoConfiguration(newTraceConfiguration)
+	wta := web.NewTestApplication(t, new(fooController), new(chatController))
+
+	t.Run("should authenticate the upgrade request if the controller declares its own middleware", func(t *testing.T) {
+		wta.Get("/chat").
+			Expect().Status(http.StatusUnauthorized).
+			Header("X-Trace").Empty()
+	})
+
+	t.Run("should apply the middleware of the controller after jwt", func(t *testing.T) {
+		token := wta.Post("/foo/login").
+			WithJSON(&userRequest{Username: "johndoe", Password: "iHop91#15"}).
+			Expect().Status(http.StatusOK).
+			JSON().Object().Value("data").String().Raw()
+
+		wta.Get("/chat").
+			WithHeader("Authorization", "Bearer "+token).
+			Expect().
+			Header("X-Trace").Equal("traced")
+	})
+}
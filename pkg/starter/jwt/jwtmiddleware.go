@@ -17,10 +17,10 @@ package jwt
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/hidevopsio/hiboot/pkg/app/web"
-	"github.com/hidevopsio/hiboot/pkg/log"
 	mwjwt "github.com/iris-contrib/middleware/jwt"
 	"github.com/kataras/iris/context"
 )
@@ -44,6 +44,9 @@ func (m *JwtMiddleware) Serve(ctx context.Context) {
 
 // CheckJWT the main functionality, checks for token
 func (m *JwtMiddleware) CheckJWT(ctx context.Context) error {
+	// the log lines carry the request id
+	logger := web.Log(ctx)
+
 	if !m.Config.EnableAuthOnOptions {
 		if ctx.Method() == http.MethodOptions {
 			return nil
@@ -55,9 +58,9 @@ func (m *JwtMiddleware) CheckJWT(ctx context.Context) error {
 
 	// If debugging is turned on, log the outcome
 	if err != nil {
-		log.Errorf("error extracting JWT: %v", err)
+		logger.Errorf("error extracting JWT: %v", err)
 	} else {
-		log.Debugf("token extracted: %s", token)
+		logger.Debugf("token extracted: %s", token)
 	}
 
 	// If an error occurs, call the error handler and return an error
@@ -69,14 +72,14 @@ func (m *JwtMiddleware) CheckJWT(ctx context.Context) error {
 	if token == "" {
 		// Check if it was required
 		if m.Config.CredentialsOptional {
-			log.Debug("  No credentials found (CredentialsOptional=true)")
+			logger.Debug("  No credentials found (CredentialsOptional=true)")
 			// No error, just no token (and that is ok given that CredentialsOptional is true)
 			return nil
 		}
 
 		// If we get here, the required token is missing
 		errorMsg := "Required authorization token not found"
-		log.Debug("  Error: No credentials found (CredentialsOptional=false)")
+		logger.Debug("  Error: No credentials found (CredentialsOptional=false)")
 		return fmt.Errorf(errorMsg)
 	}
 
@@ -84,7 +87,7 @@ func (m *JwtMiddleware) CheckJWT(ctx context.Context) error {
 	parsedToken, err := jwt.Parse(token, m.Config.ValidationKeyGetter)
 	// Check if there was an error in parsing...
 	if err != nil {
-		log.Debugf("Error parsing token: %v", err)
+		logger.Debugf("Error parsing token: %v", err)
 		return fmt.Errorf("error parsing token: %v", err)
 	}
 
@@ -92,17 +95,17 @@ func (m *JwtMiddleware) CheckJWT(ctx context.Context) error {
 		message := fmt.Sprintf("Expected %s signing method but token specified %s",
 			m.Config.SigningMethod.Alg(),
 			parsedToken.Header["alg"])
-		log.Debugf("Error validating token algorithm: %s", message)
+		logger.Debugf("Error validating token algorithm: %s", message)
 		return fmt.Errorf("error validating token algorithm: %s", message)
 	}
 
 	// Check if the parsed token is valid...
 	if !parsedToken.Valid {
-		log.Debug("Token is invalid")
+		logger.Debug("Token is invalid")
 		return fmt.Errorf("token is invalid")
 	}
 
-	log.Debugf("JWT: %v", parsedToken)
+	logger.Debugf("JWT: %v", parsedToken)
 
 	// If we get here, everything worked and we can set the
 	// user property in context.
@@ -135,3 +138,18 @@ func NewJwtMiddleware(cfg ...mwjwt.Config) *JwtMiddleware {
 
 	return &JwtMiddleware{mwjwt.Middleware{Config: c}}
 }
+
+// fromAuthHeaderOrUpgradeParameter extracts the token from the Authorization header, or the query parameter token
+// of the websocket upgrade request, as the browsers can not set the headers of websocket requests. It is only used
+// on the routes of websocket controllers, so that the tokens of other requests are never read from the query
+// parameters, which are usually written into the access logs
+func fromAuthHeaderOrUpgradeParameter(ctx context.Context) (string, error) {
+	token, err := mwjwt.FromAuthHeader(ctx)
+	if err != nil || token != "" {
+		return token, err
+	}
+	if strings.EqualFold(ctx.GetHeader("Upgrade"), "websocket") {
+		return ctx.URLParam("token"), nil
+	}
+	return "", nil
+}
@@ -16,6 +16,7 @@ package jwt
 
 import (
 	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/app/web"
 	"github.com/hidevopsio/hiboot/pkg/log"
 )
 
@@ -41,11 +42,18 @@ func (p *postProcessor) BeforeInitialization(factory interface{}) {
 func (p *postProcessor) AfterInitialization(factory interface{}) {
 	//log.Debug("[jwt] AfterInitialization")
 
+	// the websocket controllers are optional, they are registered before using jwt, as their upgrade requests
+	// are authenticated by their own middleware that also reads the token from the query parameter
+	err := p.applicationContext.RegisterController(new(JwtWebSocketController))
+	if err != nil && err != web.ErrControllersNotFound {
+		log.Warnf("[jwt] %v", err)
+	}
+
 	// use jwt
 	p.applicationContext.Use(p.jwtMiddleware.Serve)
 
 	// finally register jwt controllers
-	err := p.applicationContext.RegisterController(new(JwtController))
+	err = p.applicationContext.RegisterController(new(JwtController))
 	if err != nil {
 		log.Warnf("[jwt] %v", err)
 	}
@@ -0,0 +1,114 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"crypto/rsa"
+	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/app/web"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/kataras/iris/context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type configuration struct {
+	app.PreConfiguration
+	Properties         Properties `mapstructure:"ratelimit"`
+	applicationContext app.ApplicationContext
+}
+
+func newConfiguration(applicationContext app.ApplicationContext) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+	}
+}
+
+func init() {
+	app.AutoConfiguration(newConfiguration)
+}
+
+// RateLimitHandler config rate limit handler, the request that exceeds the limit is responded with
+// 429 Too Many Requests and Retry-After header
+func (c *configuration) RateLimitHandler() context.Handler {
+	var store Store = newMemoryStore()
+	if c.Properties.Store != "" {
+		store = &namedStore{name: c.Properties.Store, applicationContext: c.applicationContext}
+	}
+	limiter := newLimiter(&c.Properties, store, c.verifyKey)
+	rateLimitHandler := func(ctx context.Context) {
+		r := limiter.match(ctx.Method(), ctx.Path())
+		if r == nil {
+			ctx.Next()
+			return
+		}
+		result, err := limiter.take(ctx, r)
+		if err != nil {
+			// the requests are not limited if the store is unavailable
+			web.Log(ctx).Warnf("[ratelimit] %v", err)
+			ctx.Next()
+			return
+		}
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			err = &system.ErrTooManyRequests{RetryAfter: retryAfter}
+			if wc, ok := ctx.(*web.Context); ok {
+				wc.ResponseErr(err)
+			} else {
+				ctx.StatusCode(http.StatusTooManyRequests)
+			}
+			ctx.StopExecution()
+			return
+		}
+		ctx.Next()
+	}
+
+	c.applicationContext.Use(rateLimitHandler)
+
+	return rateLimitHandler
+}
+
+// verifyKey returns the public key of jwt starter if it is enabled
+func (c *configuration) verifyKey() *rsa.PublicKey {
+	if token, ok := c.applicationContext.GetInstance("jwtToken").(interface{ VerifyKey() *rsa.PublicKey }); ok {
+		return token.VerifyKey()
+	}
+	return nil
+}
+
+// namedStore resolves the store by name from the application context on the first request, as the store
+// may be instantiated after the handler, the buckets are kept in memory if the store is not found
+type namedStore struct {
+	once               sync.Once
+	name               string
+	applicationContext app.ApplicationContext
+	store              Store
+}
+
+func (s *namedStore) Take(key string, limit int, period time.Duration) (Result, error) {
+	s.once.Do(func() {
+		var ok bool
+		if s.store, ok = s.applicationContext.GetInstance(s.name).(Store); !ok {
+			log.Warnf("[ratelimit] store %v is not found, the buckets are kept in memory", s.name)
+			s.store = newMemoryStore()
+		}
+	})
+	return s.store.Take(key, limit, period)
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app/fake"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfiguration(t *testing.T) {
+	c := newConfiguration(new(fake.ApplicationContext))
+
+	t.Run("should get handler", func(t *testing.T) {
+		c.Properties = Properties{
+			Policies: []Policy{{Path: "/login", Limit: 5, Period: "1m"}},
+		}
+		h := c.RateLimitHandler()
+		assert.Equal(t, reflect.Func, reflect.TypeOf(h).Kind())
+	})
+
+	t.Run("should not get verify key if jwt is not enabled", func(t *testing.T) {
+		assert.Equal(t, true, c.verifyKey() == nil)
+	})
+
+	t.Run("should keep the buckets in memory if the store is not found", func(t *testing.T) {
+		s := &namedStore{name: "redisStore", applicationContext: new(fake.ApplicationContext)}
+		result, err := s.Take("foo", 1, time.Second)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, result.Allowed)
+		assert.IsType(t, new(memoryStore), s.store)
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+// Policy is the token bucket limit of the requests whose path matches Path, each client has its own bucket
+type Policy struct {
+	// Path is the path pattern of the routes, * matches one path segment and the trailing ** matches the rest,
+	// e.g. /login, /user/*/orders or /api/**
+	Path string `json:"path"`
+	// Methods are the http methods that the policy applies to, all methods if it is empty
+	Methods []string `json:"methods"`
+	// Limit is the number of requests that a client can make in Period, it is also the burst of the bucket
+	Limit int `json:"limit"`
+	// Period is the duration in which Limit tokens are refilled, e.g. 1s or 1m, it is 1s if it is empty
+	Period string `json:"period"`
+	// Key identifies the client, one of ip, header:<name> and claim:<name>, e.g. header:X-API-Key or claim:username,
+	// the client is identified by ip if it is empty or the header or the claim of valid JWT is not found
+	Key string `json:"key"`
+}
+
+// Properties the properties of rate limit
+type Properties struct {
+	// Policies are the policies of the routes, the first matched policy applies to the request
+	Policies []Policy `json:"policies"`
+	// Store is the name of the instance of ratelimit.Store, e.g. a store of shared backend,
+	// the buckets are kept in memory if it is empty
+	Store string `json:"store"`
+}
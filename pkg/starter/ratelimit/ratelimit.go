@@ -0,0 +1,202 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/kataras/iris/context"
+	"strings"
+	"time"
+)
+
+const (
+	keyIP     = "ip"
+	keyHeader = "header"
+	keyClaim  = "claim"
+
+	pathSep = "/"
+)
+
+var errUnexpectedSigningMethod = errors.New("unexpected jwt signing method")
+
+// rule is the parsed policy
+type rule struct {
+	index    int
+	segments []string
+	methods  []string
+	limit    int
+	period   time.Duration
+	keyType  string
+	keyName  string
+}
+
+// limiter takes the tokens of the clients from the buckets of the matched policies
+type limiter struct {
+	rules []*rule
+	store Store
+	// verifyKey returns the public key that the JWT is verified with, or nil if jwt is not enabled
+	verifyKey func() *rsa.PublicKey
+}
+
+func newLimiter(p *Properties, store Store, verifyKey func() *rsa.PublicKey) *limiter {
+	l := &limiter{store: store, verifyKey: verifyKey}
+	for i, policy := range p.Policies {
+		r, err := newRule(i, policy)
+		if err != nil {
+			log.Warnf("[ratelimit] policy %v is ignored: %v", policy.Path, err)
+			continue
+		}
+		l.rules = append(l.rules, r)
+	}
+	return l
+}
+
+func newRule(index int, p Policy) (r *rule, err error) {
+	if p.Limit <= 0 {
+		return nil, fmt.Errorf("limit %v must be greater than 0", p.Limit)
+	}
+	r = &rule{
+		index:    index,
+		segments: strings.Split(strings.Trim(p.Path, pathSep), pathSep),
+		limit:    p.Limit,
+		period:   time.Second,
+		keyType:  keyIP,
+	}
+	for _, m := range p.Methods {
+		r.methods = append(r.methods, strings.ToUpper(m))
+	}
+	if p.Period != "" {
+		if r.period, err = time.ParseDuration(p.Period); err != nil {
+			return nil, err
+		}
+		if r.period <= 0 {
+			return nil, fmt.Errorf("period %v must be greater than 0", p.Period)
+		}
+	}
+	if p.Key != "" && p.Key != keyIP {
+		kv := strings.SplitN(p.Key, ":", 2)
+		if len(kv) != 2 || kv[1] == "" || (kv[0] != keyHeader && kv[0] != keyClaim) {
+			return nil, fmt.Errorf("key %v must be one of ip, header:<name> and claim:<name>", p.Key)
+		}
+		r.keyType, r.keyName = kv[0], kv[1]
+	}
+	return
+}
+
+// matches reports whether the request of method and path is limited by the rule
+func (r *rule) matches(method, path string) bool {
+	if len(r.methods) != 0 {
+		matched := false
+		for _, m := range r.methods {
+			if m == method {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	segments := strings.Split(strings.Trim(path, pathSep), pathSep)
+	for i, s := range r.segments {
+		if s == "**" && i == len(r.segments)-1 {
+			return true
+		}
+		if i >= len(segments) || (s != "*" && s != segments[i]) {
+			return false
+		}
+	}
+	return len(segments) == len(r.segments)
+}
+
+// match returns the first rule that matches the request
+func (l *limiter) match(method, path string) *rule {
+	for _, r := range l.rules {
+		if r.matches(method, path) {
+			return r
+		}
+	}
+	return nil
+}
+
+// clientKey returns the key of the bucket of the client in the rule
+func (l *limiter) clientKey(ctx context.Context, r *rule) string {
+	var client string
+	switch r.keyType {
+	case keyHeader:
+		client = ctx.GetHeader(r.keyName)
+	case keyClaim:
+		client = l.claim(ctx, r.keyName)
+	}
+	keyType := r.keyType
+	if client == "" {
+		keyType, client = keyIP, ctx.RemoteAddr()
+	}
+	return fmt.Sprintf("%d:%s:%s", r.index, keyType, client)
+}
+
+// claim returns the claim of the valid JWT in the Authorization header, the token is verified here as
+// the rate limit applies before the jwt middleware
+func (l *limiter) claim(ctx context.Context, name string) string {
+	token, ok := ctx.Values().Get("jwt").(*jwt.Token)
+	if !ok {
+		token = l.parseToken(ctx.GetHeader("Authorization"))
+	}
+	if token == nil || !token.Valid {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims[name] == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", claims[name])
+}
+
+func (l *limiter) parseToken(authorization string) *jwt.Token {
+	fields := strings.Fields(authorization)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") || l.verifyKey == nil {
+		return nil
+	}
+	key := l.verifyKey()
+	if key == nil {
+		return nil
+	}
+	token, err := jwt.Parse(fields[1], func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+			return nil, errUnexpectedSigningMethod
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil
+	}
+	return token
+}
+
+// take takes a token of the client from the bucket of the rule, the limit headers are responded
+func (l *limiter) take(ctx context.Context, r *rule) (result Result, err error) {
+	result, err = l.store.Take(l.clientKey(ctx, r), r.limit, r.period)
+	if err != nil {
+		return
+	}
+	header := ctx.ResponseWriter().Header()
+	header.Set("X-RateLimit-Limit", fmt.Sprintf("%d", r.limit))
+	header.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
+	return
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewRule(t *testing.T) {
+	t.Run("should parse the policy", func(t *testing.T) {
+		r, err := newRule(1, Policy{Path: "/user/*", Methods: []string{"post"}, Limit: 10, Period: "1m", Key: "header:X-API-Key"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &rule{
+			index:    1,
+			segments: []string{"user", "*"},
+			methods:  []string{"POST"},
+			limit:    10,
+			period:   time.Minute,
+			keyType:  keyHeader,
+			keyName:  "X-API-Key",
+		}, r)
+	})
+
+	t.Run("should use the defaults", func(t *testing.T) {
+		r, err := newRule(0, Policy{Path: "/login", Limit: 5})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, time.Second, r.period)
+		assert.Equal(t, keyIP, r.keyType)
+	})
+
+	testData := []Policy{
+		{Path: "/login"},
+		{Path: "/login", Limit: 5, Period: "often"},
+		{Path: "/login", Limit: 5, Period: "-1s"},
+		{Path: "/login", Limit: 5, Key: "cookie:session"},
+		{Path: "/login", Limit: 5, Key: "claim:"},
+	}
+	for _, p := range testData {
+		t.Run("should report invalid policy", func(t *testing.T) {
+			_, err := newRule(0, p)
+			assert.NotEqual(t, nil, err)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	l := newLimiter(&Properties{Policies: []Policy{
+		{Path: "/login", Methods: []string{"POST"}, Limit: 1},
+		{Path: "/user/*/orders", Limit: 2},
+		{Path: "/api/**", Limit: 3},
+		{Path: "/invalid"},
+	}}, newMemoryStore(), nil)
+
+	assert.Equal(t, 3, len(l.rules))
+
+	testData := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{"POST", "/login", 1},
+		{"GET", "/login", 0},
+		{"GET", "/user/123/orders", 2},
+		{"GET", "/user/123/orders/456", 0},
+		{"GET", "/user/orders", 0},
+		{"GET", "/api", 3},
+		{"GET", "/api/foo/bar", 3},
+		{"GET", "/apis", 0},
+		{"GET", "/invalid", 0},
+	}
+	for _, data := range testData {
+		t.Run(data.method+" "+data.path, func(t *testing.T) {
+			limit := 0
+			if r := l.match(data.method, data.path); r != nil {
+				limit = r.limit
+			}
+			assert.Equal(t, data.expected, limit)
+		})
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Equal(t, nil, err)
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"username": "john"}).SignedString(key)
+	assert.Equal(t, nil, err)
+
+	l := newLimiter(new(Properties), newMemoryStore(), func() *rsa.PublicKey { return &key.PublicKey })
+
+	t.Run("should parse the valid token", func(t *testing.T) {
+		token := l.parseToken("Bearer " + signed)
+		assert.Equal(t, true, token != nil && token.Valid)
+		assert.Equal(t, "john", token.Claims.(jwt.MapClaims)["username"])
+	})
+
+	t.Run("should not parse the token signed by other key", func(t *testing.T) {
+		other, _ := rsa.GenerateKey(rand.Reader, 1024)
+		forged, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"username": "john"}).SignedString(other)
+		assert.Equal(t, (*jwt.Token)(nil), l.parseToken("Bearer "+forged))
+	})
+
+	t.Run("should not parse the token without bearer", func(t *testing.T) {
+		assert.Equal(t, (*jwt.Token)(nil), l.parseToken(signed))
+	})
+
+	t.Run("should not parse the token if jwt is not enabled", func(t *testing.T) {
+		l := newLimiter(new(Properties), newMemoryStore(), nil)
+		assert.Equal(t, (*jwt.Token)(nil), l.parseToken("Bearer "+signed))
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Result is the result of taking a token from the bucket
+type Result struct {
+	// Allowed reports whether the token is taken
+	Allowed bool
+	// Remaining is the number of tokens left in the bucket
+	Remaining int
+	// RetryAfter is the duration to wait for the next token if it is not allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets of the clients, it can be implemented with a shared backend, e.g. redis,
+// so that the instances of the application share the limits
+type Store interface {
+	// Take takes a token from the bucket of key, which holds limit tokens at most and is refilled with limit
+	// tokens in period
+	Take(key string, limit int, period time.Duration) (Result, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+// memoryStore keeps the buckets in memory, the buckets that are full are removed periodically
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+const sweepInterval = time.Minute
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take takes a token from the bucket of key
+func (s *memoryStore) Take(key string, limit int, period time.Duration) (result Result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	// tokens per nanosecond
+	rate := float64(limit) / float64(period)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), last: now, period: period}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+	result.Remaining = int(b.tokens)
+	return
+}
+
+// sweep removes the buckets that have been refilled, which are the same as the new ones
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
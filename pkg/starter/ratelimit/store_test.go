@@ -0,0 +1,59 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	s := newMemoryStore()
+	s.now = func() time.Time { return now }
+
+	t.Run("should take the tokens of burst", func(t *testing.T) {
+		for i := 2; i >= 0; i-- {
+			result, err := s.Take("foo", 3, time.Second)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, Result{Allowed: true, Remaining: i}, result)
+		}
+	})
+
+	t.Run("should not take the token if the bucket is empty", func(t *testing.T) {
+		result, err := s.Take("foo", 3, time.Second)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, result.Allowed)
+		assert.Equal(t, time.Second/3+1, result.RetryAfter)
+	})
+
+	t.Run("should keep the buckets of the keys separately", func(t *testing.T) {
+		result, _ := s.Take("bar", 3, time.Second)
+		assert.Equal(t, true, result.Allowed)
+	})
+
+	t.Run("should refill the bucket after retry", func(t *testing.T) {
+		now = now.Add(time.Second/3 + 1)
+		result, _ := s.Take("foo", 3, time.Second)
+		assert.Equal(t, Result{Allowed: true, Remaining: 0}, result)
+	})
+
+	t.Run("should remove the full buckets", func(t *testing.T) {
+		now = now.Add(2 * sweepInterval)
+		s.Take("baz", 3, time.Second)
+		assert.Equal(t, 1, len(s.buckets))
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app"
+	"github.com/hidevopsio/hiboot/pkg/app/web"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/iris-contrib/i18n"
+	"github.com/kataras/iris/view"
+)
+
+const (
+	engineHTML       = "html"
+	engineDjango     = "django"
+	engineHandlebars = "handlebars"
+	enginePug        = "pug"
+	engineAmber      = "amber"
+
+	profileDev = "dev"
+
+	funcTranslate = "tr"
+)
+
+// funcAdder is implemented by the template engines that the functions can be added to
+type funcAdder interface {
+	AddFunc(funcName string, funcBody interface{})
+}
+
+type configuration struct {
+	app.PreConfiguration
+	Properties         Properties `mapstructure:"view"`
+	applicationContext app.ApplicationContext
+	systemConfig       *system.Configuration
+}
+
+func newConfiguration(applicationContext app.ApplicationContext, systemConfig *system.Configuration) *configuration {
+	return &configuration{
+		applicationContext: applicationContext,
+		systemConfig:       systemConfig,
+	}
+}
+
+func init() {
+	app.AutoConfiguration(newConfiguration)
+}
+
+// ViewEngine config the template engine that renders the model.View returned by controller methods, the application
+// exits if the engine is not supported
+func (c *configuration) ViewEngine() view.Engine {
+	p := c.Properties
+	if c.systemConfig != nil && c.systemConfig.App.Profiles.Active == profileDev {
+		p.Reload = true
+	}
+	engine, err := newEngine(&p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the i18n function translates to the language of the language files of the locale starter, the language of
+	// the request is passed by the template, e.g. {{tr .lang "welcome" .name}}
+	if fa, ok := engine.(funcAdder); ok {
+		fa.AddFunc(funcTranslate, i18n.Tr)
+	}
+
+	if vr, ok := c.applicationContext.(web.ViewRegistry); ok {
+		vr.RegisterView(engine)
+	}
+
+	return engine
+}
+
+// newEngine creates the template engine of the properties, it returns error if the engine is not supported
+func newEngine(p *Properties) (view.Engine, error) {
+	dir, ext := p.Dir, p.Extension
+	switch p.Engine {
+	case engineHTML, "":
+		return view.HTML(dir, ext).Layout(p.Layout).Reload(p.Reload), nil
+	case enginePug:
+		return view.Pug(dir, ext).Layout(p.Layout).Reload(p.Reload), nil
+	case engineHandlebars:
+		return view.Handlebars(dir, ext).Layout(p.Layout).Reload(p.Reload), nil
+	case engineDjango:
+		return view.Django(dir, ext).Reload(p.Reload), nil
+	case engineAmber:
+		return view.Amber(dir, ext).Reload(p.Reload), nil
+	}
+	return nil, &system.ErrUnsupportedViewEngine{Engine: p.Engine}
+}
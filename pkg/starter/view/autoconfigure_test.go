@@ -0,0 +1,49 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/app/fake"
+	"github.com/hidevopsio/hiboot/pkg/system"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfiguration(t *testing.T) {
+	c := newConfiguration(new(fake.ApplicationContext), &system.Configuration{
+		App: system.App{Profiles: system.Profiles{Active: profileDev}},
+	})
+
+	t.Run("should get view engine", func(t *testing.T) {
+		c.Properties = Properties{Engine: "html", Dir: "./templates", Extension: ".html", Layout: "layout.html"}
+		assert.NotEqual(t, nil, c.ViewEngine())
+	})
+}
+
+func TestNewEngine(t *testing.T) {
+	for _, engine := range []string{"", engineHTML, engineDjango, engineHandlebars, enginePug, engineAmber} {
+		t.Run("should create "+engine+" engine", func(t *testing.T) {
+			e, err := newEngine(&Properties{Engine: engine, Dir: "./templates", Extension: ".html", Reload: true})
+			assert.Equal(t, nil, err)
+			assert.NotEqual(t, nil, e)
+		})
+	}
+
+	t.Run("should not create unsupported engine", func(t *testing.T) {
+		e, err := newEngine(&Properties{Engine: "jsp", Dir: "./templates", Extension: ".jsp"})
+		assert.Equal(t, &system.ErrUnsupportedViewEngine{Engine: "jsp"}, err)
+		assert.Equal(t, nil, e)
+	})
+}
@@ -0,0 +1,29 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package view
+
+// Properties the properties of view
+type Properties struct {
+	// Engine is the template engine, one of html, django, handlebars, pug and amber
+	Engine string `json:"engine" default:"html"`
+	// Dir is the directory of the templates
+	Dir string `json:"dir" default:"./templates"`
+	// Extension is the extension of the template files
+	Extension string `json:"extension" default:".html"`
+	// Layout is the default layout template of html, pug and handlebars engines, e.g. layouts/main.html
+	Layout string `json:"layout"`
+	// Reload reloads the templates on each request, it is always enabled in the dev profile
+	Reload bool `json:"reload"`
+}
@@ -15,7 +15,9 @@
 package system
 
 type Configuration struct {
-	App     App     `mapstructure:"app"`
-	Server  Server  `mapstructure:"server"`
-	Logging Logging `mapstructure:"logging"`
+	App     App             `mapstructure:"app"`
+	Server  Server          `mapstructure:"server"`
+	Logging Logging         `mapstructure:"logging"`
+	Sources PropertySources `mapstructure:"sources"`
+	OpenAPI OpenAPI         `mapstructure:"openapi"`
 }
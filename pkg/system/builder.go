@@ -22,15 +22,29 @@ import (
 	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/hidevopsio/hiboot/pkg/utils/io"
 	"github.com/hidevopsio/hiboot/pkg/utils/reflector"
+	"github.com/hidevopsio/hiboot/pkg/utils/str"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 	"path/filepath"
 	"reflect"
+	"strings"
 )
 
+// configExts is the precedence of the config file extensions, the first one found is used if there are
+// several config files with the same name in different formats, and FileType always goes first
+var configExts = []string{"yml", "yaml", "json", "toml", "properties", "props", "prop", "hcl"}
+
 type Builder struct {
-	Path       string
-	Name       string
+	// Path is the first directory to search the config files in
+	Path string
+	// Paths are the extra directories to search the config files in, after Path
+	Paths []string
+	Name  string
+	// ConfigFile is the optional config file outside of the search paths, e.g. a mounted file in container,
+	// it is merged on top of the other config files if it exists
+	ConfigFile string
+	// Sources are the property sources that are read after all local config files
+	Sources    []PropertySource
 	FileType   string
 	Profile    string
 	ConfigType interface{}
@@ -39,24 +53,87 @@ type Builder struct {
 // create new viper instance
 func (b *Builder) New(name string) *viper.Viper {
 	v := viper.New()
-	v.AddConfigPath(b.Path)
+	for _, path := range b.configPaths() {
+		v.AddConfigPath(path)
+	}
 	v.SetConfigName(name)
-	v.SetConfigType(b.FileType)
+	// the config type is detected by file extension
+	if configFile := b.findConfigFile(name); configFile != "" {
+		v.SetConfigFile(configFile)
+	}
 	return v
 }
 
+// exts returns the supported config file extensions in order of precedence
+func (b *Builder) exts() (exts []string) {
+	if b.FileType != "" {
+		exts = append(exts, b.FileType)
+	}
+	for _, ext := range configExts {
+		if ext != b.FileType && str.InSlice(ext, viper.SupportedExts) {
+			exts = append(exts, ext)
+		}
+	}
+	return
+}
+
+// ConfigFiles returns the candidates of the config file with the name in search paths in order of precedence
+func (b *Builder) ConfigFiles(name string) (files []string) {
+	for _, path := range b.configPaths() {
+		files = append(files, b.configFiles(path, name)...)
+	}
+	return
+}
+
+// configFiles returns the candidates of the config file with the name in path in order of precedence
+func (b *Builder) configFiles(path, name string) (files []string) {
+	for _, ext := range b.exts() {
+		files = append(files, filepath.Join(path, name+"."+ext))
+	}
+	return
+}
+
+// findConfigFile find the config file in search paths, the first path that contains the config file wins,
+// then the file extension is chosen in order of precedence
+func (b *Builder) findConfigFile(name string) (configFile string) {
+	for _, path := range b.configPaths() {
+		var found []string
+		for _, file := range b.configFiles(path, name) {
+			if !io.IsPathNotExist(file) {
+				found = append(found, file)
+			}
+		}
+		if len(found) != 0 {
+			if len(found) > 1 {
+				log.Warnf("[system] found config files %v, only %v is used", strings.Join(found, ", "), found[0])
+			}
+			return found[0]
+		}
+	}
+	return
+}
+
+// configPaths returns all search paths in order
+func (b *Builder) configPaths() (paths []string) {
+	if b.Path != "" {
+		paths = append(paths, b.Path)
+	}
+	for _, path := range b.Paths {
+		if path != "" && path != b.Path {
+			paths = append(paths, path)
+		}
+	}
+	return
+}
+
 // create file if it's not exist
 func (b *Builder) Init() error {
 	return io.CreateFile(b.Path, b.Name+"."+b.FileType)
 }
 
-func (b *Builder) isFileNotExist(path string) bool {
-	for _, ext := range viper.SupportedExts {
-		if !io.IsPathNotExist(path + ext) {
-			return false
-		}
-	}
-	return true
+// isConfigNotExist check if the config file does not exist in any of the search paths
+func (b *Builder) isConfigNotExist(name string) bool {
+	return b.findConfigFile(name) == ""
 }
 
 // build config file
@@ -75,19 +152,28 @@ func (b *Builder) Build(profiles ...string) (interface{}, error) {
 	for _, profile := range profiles {
 		name := b.Name + "-" + profile
 		// allow the empty of the profile
-		configFile := filepath.Join(b.Path, name)
-		if profile == "" || b.isFileNotExist(configFile+".") {
-			//log.Debugf("config file: %v does not exist", configFile)
-			return conf, nil
+		if profile == "" || b.isConfigNotExist(name) {
+			//log.Debugf("config file: %v does not exist", name)
+			break
 		}
 
 		_, err = b.Read(name)
 		if err != nil {
-			log.Errorf("failed to read config file: %v", configFile)
+			log.Errorf("failed to read config file: %v", name)
 			break
 		}
 	}
 
+	err = b.readConfigFile(conf)
+	if err != nil {
+		log.Errorf("failed to read config file: %v", b.ConfigFile)
+	}
+
+	err = b.ReadSources(conf)
+	if err != nil {
+		log.Error(err)
+	}
+
 	return conf, nil
 }
 
@@ -95,7 +181,7 @@ func (b *Builder) Build(profiles ...string) (interface{}, error) {
 func (b *Builder) BuildWithProfile() (interface{}, error) {
 	name := b.Name + "-" + b.Profile
 	// allow the empty of the profile
-	if b.Profile == "" || b.isFileNotExist(filepath.Join(b.Path, name)+".") {
+	if b.Profile == "" || b.isConfigNotExist(name) {
 		return reflector.NewReflectType(b.ConfigType), nil
 	}
 
@@ -108,8 +194,41 @@ func (b *Builder) BuildWithProfile() (interface{}, error) {
 
 // Read single file
 func (b *Builder) Read(name string) (interface{}, error) {
+	return b.unmarshal(b.New(name))
+}
+
+// readConfigFile read the optional config file into conf, nothing to do if it does not exist
+func (b *Builder) readConfigFile(conf interface{}) (err error) {
+	if b.ConfigFile == "" || io.IsPathNotExist(b.ConfigFile) {
+		return
+	}
+
+	v := viper.New()
+	v.SetConfigFile(b.ConfigFile)
+	err = v.ReadInConfig()
+	if err == nil {
+		err = v.Unmarshal(conf)
+	}
+	return
+}
+
+// ReadSources read the properties of all property sources into conf in order
+func (b *Builder) ReadSources(conf interface{}) (err error) {
+	for _, source := range b.Sources {
+		var props map[string]interface{}
+		props, err = source.Properties()
+		if err == nil {
+			err = UnmarshalProperties(props, conf)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read property source %v: %v", source.Name(), err)
+		}
+	}
+	return
+}
 
-	v := b.New(name)
+// unmarshal read config by viper and unmarshal it to ConfigType
+func (b *Builder) unmarshal(v *viper.Viper) (interface{}, error) {
 	err := v.ReadInConfig()
 	if err != nil {
 		return nil, fmt.Errorf("error on config file: %s", err)
@@ -134,6 +253,7 @@ func (b *Builder) Read(name string) (interface{}, error) {
 func (b *Builder) Save(p interface{}) error {
 
 	v := b.New(b.Name)
+	v.SetConfigType(b.FileType)
 
 	y, err := yaml.Marshal(p)
 	if err != nil {
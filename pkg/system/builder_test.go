@@ -224,3 +224,98 @@ func TestBuilderSave(t *testing.T) {
 		assert.Contains(t, err.Error(), "wrong")
 	})
 }
+
+func TestBuilderWithConfigPathsAndFile(t *testing.T) {
+	tmpPath := filepath.Join(os.TempDir(), "builder-paths")
+	configFile := filepath.Join(tmpPath, "override.yml")
+	io.WriterFile(tmpPath, "override.yml", []byte("server:\n  port: 9090\n"))
+	defer os.RemoveAll(tmpPath)
+
+	b := &Builder{
+		Path:       tmpPath,
+		Paths:      []string{filepath.Join(io.GetWorkDir(), "config")},
+		Name:       "application",
+		FileType:   "yaml",
+		Profile:    "local",
+		ConfigType: new(Configuration),
+	}
+
+	t.Run("should find config file in the extra search paths", func(t *testing.T) {
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "hiboot", c.App.Name)
+		assert.Equal(t, "8080", c.Server.Port)
+	})
+
+	t.Run("should merge the optional config file on top of the others", func(t *testing.T) {
+		b.ConfigFile = configFile
+		b.ConfigType = new(Configuration)
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "hiboot", c.App.Name)
+		assert.Equal(t, "9090", c.Server.Port)
+	})
+
+	t.Run("should ignore the optional config file if it does not exist", func(t *testing.T) {
+		b.ConfigFile = filepath.Join(tmpPath, "does-not-exist.yml")
+		b.ConfigType = new(Configuration)
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "8080", c.Server.Port)
+	})
+}
+
+func TestBuilderWithFileFormats(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "builder-formats")
+	os.RemoveAll(path)
+	defer os.RemoveAll(path)
+
+	io.WriterFile(path, "application.json", []byte(`{"app": {"name": "json-app", "project": "hidevopsio"}}`))
+	io.WriterFile(path, "application-dev.toml", []byte("[server]\nport = \"8081\"\n"))
+
+	b := &Builder{
+		Path:       path,
+		Name:       "application",
+		FileType:   "yaml",
+		Profile:    "dev",
+		ConfigType: new(Configuration),
+	}
+
+	t.Run("should read json and toml config files", func(t *testing.T) {
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "json-app", c.App.Name)
+		assert.Equal(t, "8081", c.Server.Port)
+	})
+
+	t.Run("should use the preferred file type if there are several formats", func(t *testing.T) {
+		io.WriterFile(path, "application.yaml", []byte("app:\n  name: yaml-app\n"))
+		b.ConfigType = new(Configuration)
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "yaml-app", c.App.Name)
+	})
+
+	t.Run("should use yml before json if file type is not specified", func(t *testing.T) {
+		io.WriterFile(path, "application.yml", []byte("app:\n  name: yml-app\n"))
+		b.FileType = ""
+		b.ConfigType = new(Configuration)
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "yml-app", c.App.Name)
+	})
+
+	t.Run("should list the config file candidates in order of precedence", func(t *testing.T) {
+		b := &Builder{Path: "config", Paths: []string{"conf"}, Name: "application", FileType: "yaml"}
+		files := b.ConfigFiles("app")
+		assert.Equal(t, filepath.Join("config", "app.yaml"), files[0])
+		assert.Equal(t, filepath.Join("config", "app.yml"), files[1])
+		assert.Contains(t, files, filepath.Join("conf", "app.json"))
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/utils/io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DotEnv is the file name of dotenv file
+const DotEnv = ".env"
+
+// LoadEnv load environment variables from dotenv files in order, the environment variables that are
+// already set are never overridden, and the files that do not exist are ignored
+func LoadEnv(files ...string) error {
+	for _, file := range files {
+		if file == "" || io.IsPathNotExist(file) {
+			continue
+		}
+		env, err := readEnv(file)
+		if err != nil {
+			return err
+		}
+		for _, e := range env {
+			if _, ok := os.LookupEnv(e.Name); !ok {
+				os.Setenv(e.Name, e.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// readEnv read the lines in form of KEY=VALUE, the comment lines start with # and the export prefix are ignored
+func readEnv(file string) (env []Env, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid line %v in %v: %v", n, file, line)
+		}
+		name := strings.TrimSpace(line[:i])
+		var value string
+		value, err = parseEnvValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %v at line %v in %v: %v", name, n, file, err)
+		}
+		env = append(env, Env{Name: name, Value: value})
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseEnvValue unquote double quoted value with escapes, strip single quoted value,
+// or remove the inline comment of unquoted value
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"github.com/hidevopsio/hiboot/pkg/utils/io"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "dotenv")
+	os.RemoveAll(path)
+	defer os.RemoveAll(path)
+
+	content := "# comment line\n" +
+		"HIBOOT_FOO=foo\n" +
+		"export HIBOOT_BAR = bar # inline comment\n" +
+		"HIBOOT_QUOTED=\"hello\\nworld\"\n" +
+		"HIBOOT_SINGLE='${not.replaced}'\n" +
+		"HIBOOT_EXISTS=from-file\n"
+	io.WriterFile(path, DotEnv, []byte(content))
+
+	os.Setenv("HIBOOT_EXISTS", "from-env")
+	defer func() {
+		for _, name := range []string{"HIBOOT_FOO", "HIBOOT_BAR", "HIBOOT_QUOTED", "HIBOOT_SINGLE", "HIBOOT_EXISTS"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	t.Run("should load env from dotenv file", func(t *testing.T) {
+		err := LoadEnv(filepath.Join(path, DotEnv), filepath.Join(path, "does-not-exist"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "foo", os.Getenv("HIBOOT_FOO"))
+		assert.Equal(t, "bar", os.Getenv("HIBOOT_BAR"))
+		assert.Equal(t, "hello\nworld", os.Getenv("HIBOOT_QUOTED"))
+		assert.Equal(t, "${not.replaced}", os.Getenv("HIBOOT_SINGLE"))
+		assert.Equal(t, "from-env", os.Getenv("HIBOOT_EXISTS"))
+	})
+
+	t.Run("should report error on invalid line", func(t *testing.T) {
+		io.WriterFile(path, "invalid.env", []byte("INVALID\n"))
+		err := LoadEnv(filepath.Join(path, "invalid.env"))
+		assert.NotEqual(t, nil, err)
+	})
+}
@@ -14,7 +14,20 @@
 
 package system
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusCoder is implemented by the errors that are responded with specific http status code
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// ErrorCoder is implemented by the errors that carry the error code for clients, e.g. not_found
+type ErrorCoder interface {
+	ErrorCode() string
+}
 
 type ErrInvalidController struct {
 	Name string
@@ -34,3 +47,252 @@ func (e *ErrNotFound) Error() string {
 	// TODO: locale
 	return fmt.Sprintf("%v is not found", e.Name)
 }
+
+func (e *ErrNotFound) StatusCode() int {
+	return http.StatusNotFound
+}
+
+func (e *ErrNotFound) ErrorCode() string {
+	return "not_found"
+}
+
+// ErrBadRequest the request is invalid, the message can be the key of i18n message
+type ErrBadRequest struct {
+	Message string
+}
+
+func (e *ErrBadRequest) Error() string {
+	return e.Message
+}
+
+func (e *ErrBadRequest) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e *ErrBadRequest) ErrorCode() string {
+	return "bad_request"
+}
+
+// ErrUnauthorized the request is not authenticated, the message can be the key of i18n message
+type ErrUnauthorized struct {
+	Message string
+}
+
+func (e *ErrUnauthorized) Error() string {
+	return e.Message
+}
+
+func (e *ErrUnauthorized) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+func (e *ErrUnauthorized) ErrorCode() string {
+	return "unauthorized"
+}
+
+// ErrForbidden the request is not allowed, the message can be the key of i18n message
+type ErrForbidden struct {
+	Message string
+}
+
+func (e *ErrForbidden) Error() string {
+	return e.Message
+}
+
+func (e *ErrForbidden) StatusCode() int {
+	return http.StatusForbidden
+}
+
+func (e *ErrForbidden) ErrorCode() string {
+	return "forbidden"
+}
+
+// ErrConflict resource is conflict with the existing one
+type ErrConflict struct {
+	Name string
+}
+
+func (e *ErrConflict) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("%v is already exist", e.Name)
+}
+
+func (e *ErrConflict) StatusCode() int {
+	return http.StatusConflict
+}
+
+func (e *ErrConflict) ErrorCode() string {
+	return "conflict"
+}
+
+// ErrInvalidRequestMapping the request mapping of controller method is invalid
+type ErrInvalidRequestMapping struct {
+	Name    string
+	Mapping string
+}
+
+func (e *ErrInvalidRequestMapping) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("invalid request mapping %v of %v, e.g. GET /path", e.Mapping, e.Name)
+}
+
+// ErrUnboundArgument the primitive argument of controller method is neither a path param nor named by request mapping
+type ErrUnboundArgument struct {
+	Name  string
+	Index int
+}
+
+func (e *ErrUnboundArgument) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("argument %v of %v is not bound, name it in the query of request mapping, e.g. GET /path?name", e.Index, e.Name)
+}
+
+// ErrInvalidMiddleware the middleware is not found or it is not a handler
+type ErrInvalidMiddleware struct {
+	Name string
+}
+
+func (e *ErrInvalidMiddleware) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("middleware %v is not found or it is not a handler", e.Name)
+}
+
+// ErrInvalidCorsPolicy the cors policy allows the credentials of all origins
+type ErrInvalidCorsPolicy struct {
+	Path string
+}
+
+func (e *ErrInvalidCorsPolicy) Error() string {
+	// TODO: locale
+	policy := "the default cors policy"
+	if e.Path != "" {
+		policy = "the cors policy of path " + e.Path
+	}
+	return fmt.Sprintf("invalid %v, the allowed origins must be listed explicitly instead of * if the credentials are allowed", policy)
+}
+
+// ErrUnsupportedViewEngine the template engine of the view starter is not supported
+type ErrUnsupportedViewEngine struct {
+	Engine string
+}
+
+func (e *ErrUnsupportedViewEngine) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("unsupported template engine %v, it should be one of html, django, handlebars, pug and amber", e.Engine)
+}
+
+// ErrInvalidMiddlewareMapping the key of middleware mapping matches none of the methods of controller
+type ErrInvalidMiddlewareMapping struct {
+	Name string
+	Key  string
+}
+
+func (e *ErrInvalidMiddlewareMapping) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("invalid middleware mapping %v of %v, it should be *, the method name or its prefix that ends with *", e.Key, e.Name)
+}
+
+// ErrInvalidWebSocketMethod the method of websocket controller does not match the convention
+type ErrInvalidWebSocketMethod struct {
+	Name string
+}
+
+func (e *ErrInvalidWebSocketMethod) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("invalid websocket method %v, e.g. OnConnect(conn *web.Conn) error, "+
+		"OnMessage(conn *web.Conn, message T) (R, error) or OnDisconnect(conn *web.Conn)", e.Name)
+}
+
+// ErrUnsupportedVersion the api version requested by header or media type is not served by the route
+type ErrUnsupportedVersion struct {
+	Version string
+}
+
+func (e *ErrUnsupportedVersion) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("api version %v is not supported", e.Version)
+}
+
+func (e *ErrUnsupportedVersion) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e *ErrUnsupportedVersion) ErrorCode() string {
+	return "unsupported_version"
+}
+
+// ErrTooManyRequests the request exceeds the rate limit, it can be retried after RetryAfter seconds
+type ErrTooManyRequests struct {
+	RetryAfter int
+}
+
+func (e *ErrTooManyRequests) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("too many requests, retry after %v seconds", e.RetryAfter)
+}
+
+func (e *ErrTooManyRequests) StatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+func (e *ErrTooManyRequests) ErrorCode() string {
+	return "too_many_requests"
+}
+
+// ErrInvalidParameter the request parameter can not be converted to the expected type
+type ErrInvalidParameter struct {
+	Name  string
+	Value string
+	Type  string
+}
+
+func (e *ErrInvalidParameter) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("invalid parameter %v=%v, %v is expected", e.Name, e.Value, e.Type)
+}
+
+func (e *ErrInvalidParameter) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e *ErrInvalidParameter) ErrorCode() string {
+	return "invalid_parameter"
+}
+
+// ErrRequestTooLarge the size of request exceeds the limit
+type ErrRequestTooLarge struct {
+	Limit int64
+}
+
+func (e *ErrRequestTooLarge) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("request body is larger than %v bytes", e.Limit)
+}
+
+func (e *ErrRequestTooLarge) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (e *ErrRequestTooLarge) ErrorCode() string {
+	return "request_too_large"
+}
+
+// ErrFileTooLarge the size of uploaded file exceeds the limit
+type ErrFileTooLarge struct {
+	Name  string
+	Size  int64
+	Limit int64
+}
+
+func (e *ErrFileTooLarge) Error() string {
+	// TODO: locale
+	return fmt.Sprintf("file %v of %v bytes is larger than %v bytes", e.Name, e.Size, e.Limit)
+}
+
+func (e *ErrFileTooLarge) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (e *ErrFileTooLarge) ErrorCode() string {
+	return "file_too_large"
+}
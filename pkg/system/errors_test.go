@@ -16,6 +16,7 @@ package system
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 )
 
@@ -30,3 +31,53 @@ func TestNotFoundError(t *testing.T) {
 
 	assert.Equal(t, "TestObject is not found", err.Error())
 }
+
+func TestInvalidMiddlewareError(t *testing.T) {
+	err := ErrInvalidMiddleware{Name: "auth"}
+
+	assert.Equal(t, "middleware auth is not found or it is not a handler", err.Error())
+}
+
+func TestRequestTooLargeError(t *testing.T) {
+	err := &ErrRequestTooLarge{Limit: 1024}
+
+	assert.Equal(t, "request body is larger than 1024 bytes", err.Error())
+	assert.Equal(t, 413, err.StatusCode())
+	assert.Equal(t, "request_too_large", err.ErrorCode())
+}
+
+func TestFileTooLargeError(t *testing.T) {
+	err := &ErrFileTooLarge{Name: "avatar", Size: 2048, Limit: 1024}
+
+	assert.Equal(t, "file avatar of 2048 bytes is larger than 1024 bytes", err.Error())
+	assert.Equal(t, 413, err.StatusCode())
+	assert.Equal(t, "file_too_large", err.ErrorCode())
+}
+
+func TestInvalidWebSocketMethodError(t *testing.T) {
+	err := ErrInvalidWebSocketMethod{Name: "ChatController.OnMessage"}
+
+	assert.Contains(t, err.Error(), "invalid websocket method ChatController.OnMessage")
+}
+
+func TestTooManyRequestsError(t *testing.T) {
+	err := ErrTooManyRequests{RetryAfter: 2}
+
+	assert.Equal(t, "too many requests, retry after 2 seconds", err.Error())
+	assert.Equal(t, http.StatusTooManyRequests, err.StatusCode())
+	assert.Equal(t, "too_many_requests", err.ErrorCode())
+}
+
+func TestUnsupportedVersionError(t *testing.T) {
+	err := ErrUnsupportedVersion{Version: "v3"}
+
+	assert.Equal(t, "api version v3 is not supported", err.Error())
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode())
+	assert.Equal(t, "unsupported_version", err.ErrorCode())
+}
+
+func TestUnsupportedViewEngineError(t *testing.T) {
+	err := ErrUnsupportedViewEngine{Engine: "jsp"}
+
+	assert.Equal(t, "unsupported template engine jsp, it should be one of html, django, handlebars, pug and amber", err.Error())
+}
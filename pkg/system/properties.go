@@ -29,12 +29,179 @@ type App struct {
 
 type Server struct {
 	Port string `json:"port" default:"8080"`
+	// WrapResponse wraps the returned struct, slice or other values of controller methods in the base response
+	WrapResponse bool `json:"wrap_response"`
+	// Shutdown is the properties of graceful shutdown
+	Shutdown Shutdown `json:"shutdown"`
+	// TLS is the properties of https server
+	TLS TLS `json:"tls"`
+	// Multipart is the properties of multipart requests
+	Multipart Multipart `json:"multipart"`
+	// WebSocket is the properties of websocket connections
+	WebSocket WebSocket `json:"websocket"`
+	// Versioning is the properties of api versioning of controllers
+	Versioning Versioning `json:"versioning"`
+	// Static are the static assets that are served at their paths
+	Static []Static `json:"static"`
+	// Compression is the properties of response compression
+	Compression Compression `json:"compression"`
+	// ETag responds the weak ETag of json responses, and 304 Not Modified if it matches If-None-Match header, the
+	// json responses are buffered to be hashed if it is enabled
+	ETag bool `json:"etag"`
+}
+
+// Compression is the properties of gzip or deflate compression of the responses
+type Compression struct {
+	// Enabled compresses the responses if the client accepts gzip or deflate encoding
+	Enabled bool `json:"enabled"`
+	// MinSize is the min size of the responses that are compressed, e.g. 1KB
+	MinSize string `json:"min_size" default:"1KB"`
+	// Level is the compression level from 1 (best speed) to 9 (best compression), 0 or -1 is the default level
+	Level int `json:"level" default:"-1"`
+	// Types are the prefixes of the content types that are compressed
+	Types []string `json:"types" default:"text/,application/json,application/javascript,application/xml,application/x-yaml,image/svg+xml"`
+}
+
+// Static is the static assets that are served at Path, e.g. the single page app at / with the api at /api
+type Static struct {
+	// Path is the path that the assets are served at, e.g. / or /assets
+	Path string `json:"path"`
+	// Dir is the directory of the assets
+	Dir string `json:"dir"`
+	// FileSystem is the name of the file system added by web.AddFileSystem, e.g. the embedded assets,
+	// it takes precedence over Dir
+	FileSystem string `json:"file_system"`
+	// Index is the index file of the directories, it is index.html if it is empty
+	Index string `json:"index"`
+	// Fallback serves the index file at Path for the html requests whose paths are not found,
+	// e.g. the routes of single page app
+	Fallback bool `json:"fallback"`
+	// Exclude are the path prefixes that are not served, e.g. /api
+	Exclude []string `json:"exclude"`
+	// CacheControl is the Cache-Control header of the assets other than the index file,
+	// e.g. public, max-age=31536000
+	CacheControl string `json:"cache_control"`
+	// Precompressed serves the .br or .gz variant of the asset if it exists and the client accepts the encoding
+	Precompressed bool `json:"precompressed"`
+}
+
+// Versioning is the properties of api versioning, the version of controller is given by the version tag of
+// the embedded controller, e.g. web.Controller `version:"v2"`
+type Versioning struct {
+	// Strategy is how the version is requested, one of path, header and media_type, e.g. /v2/user,
+	// X-API-Version: v2 or Accept: application/vnd.hiboot.v2+json
+	Strategy string `json:"strategy" default:"path"`
+	// Header is the request header of the version if strategy is header
+	Header string `json:"header" default:"X-API-Version"`
+	// DefaultVersion is the version that is served if the version is not requested, it is also served
+	// on the path without version prefix if strategy is path
+	DefaultVersion string `json:"default_version"`
+}
+
+// WebSocket is the properties of websocket connections
+type WebSocket struct {
+	// AllowedOrigins are the origins that are allowed to connect, * allows all origins,
+	// only the same origin is allowed if it is empty
+	AllowedOrigins []string `json:"allowed_origins"`
+	// MaxMessageSize is the max size of the messages read from the client, e.g. 64KB
+	MaxMessageSize string `json:"max_message_size" default:"64KB"`
+	// PingInterval is the interval of the ping messages that keep the connection alive, e.g. 30s
+	PingInterval string `json:"ping_interval" default:"30s"`
+	// SendBufferSize is the number of messages buffered for each connection, the slow connection is closed
+	// if its buffer is full
+	SendBufferSize int `json:"send_buffer_size" default:"256"`
+}
+
+// Multipart is the size limits of multipart requests, e.g. 512KB, 32MB or 1GB, 0 means unlimited
+type Multipart struct {
+	// MaxMemory is the max size of the parts that are kept in memory, the rest are stored in temp files
+	MaxMemory string `json:"max_memory" default:"32MB"`
+	// MaxRequestSize is the max size of the multipart request
+	MaxRequestSize string `json:"max_request_size" default:"64MB"`
+	// MaxFileSize is the max size of each uploaded file
+	MaxFileSize string `json:"max_file_size" default:"32MB"`
+}
+
+// TLS is the properties of https server
+type TLS struct {
+	// Enabled serves https on server.port
+	Enabled bool `json:"enabled"`
+	// CertFile is the certificate file in PEM format, the intermediate certificates can be appended to it
+	CertFile string `json:"cert_file"`
+	// KeyFile is the private key file of the certificate in PEM format
+	KeyFile string `json:"key_file"`
+	// MinVersion is the minimum TLS version, one of 1.0, 1.1, 1.2 and 1.3
+	MinVersion string `json:"min_version" default:"1.2"`
+	// CipherSuites is the names of enabled cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	// the default cipher suites are enabled if it is empty
+	CipherSuites []string `json:"cipher_suites"`
+	// ClientAuth is the policy of client certificate authentication, one of none, request, require_any,
+	// verify_if_given, require and require_and_verify, the client certificates are verified by client_ca_file
+	// except none, request and require_any, require is the alias of require_and_verify
+	ClientAuth string `json:"client_auth" default:"none"`
+	// ClientCAFile is the CA bundle in PEM format that verifies the client certificates, it is required if the
+	// client certificates are verified
+	ClientCAFile string `json:"client_ca_file"`
+	// HTTP2 enables HTTP/2
+	HTTP2 bool `json:"http2" default:"true"`
+	// RedirectPort is the port of http server that redirects all requests to https, it is disabled if it is empty
+	RedirectPort string `json:"redirect_port"`
+}
+
+// Shutdown is the properties of graceful shutdown on SIGINT or SIGTERM
+type Shutdown struct {
+	// Graceful enables graceful shutdown, which marks readiness down, drains the active requests,
+	// then calls the destroy hooks of the instances, it is enabled unless it is set to false
+	Graceful *bool `json:"graceful" default:"true"`
+	// Delay is the duration between marking readiness down and stop accepting new requests, e.g. 5s,
+	// so that the load balancer has time to remove the instance
+	Delay string `json:"delay" default:"0s"`
+	// Timeout is the max duration to wait for the active requests to complete, e.g. 30s
+	Timeout string `json:"timeout" default:"30s"`
+}
+
+// IsGraceful returns true unless graceful shutdown is disabled by graceful: false
+func (s *Shutdown) IsGraceful() bool {
+	return s.Graceful == nil || *s.Graceful
 }
 
 type Logging struct {
 	Level string `json:"level" default:"info"`
 }
 
+// PropertySources is the properties of the property sources that are read after local config files
+type PropertySources struct {
+	// URL of the http property source that returns properties in json object
+	URL string `json:"url"`
+	// Dir is the directory that each file name is the property key and its content is the value,
+	// e.g. the mounted Kubernetes ConfigMap
+	Dir string `json:"dir"`
+	// Interval is the refresh interval of the property sources, e.g. 30s, they are not refreshed if it is empty.
+	// The configurations are replaced on refresh, the instances that are created from them keep the old values
+	// unless they implement factory.RefreshListener to look the configurations up again
+	Interval string `json:"interval"`
+}
+
+// OpenAPI is the properties of the OpenAPI document that is generated from the controllers
+type OpenAPI struct {
+	// Enabled serves the OpenAPI document and the Swagger UI
+	Enabled bool `json:"enabled"`
+	// Path is the path of the OpenAPI document in json
+	Path string `json:"path" default:"/openapi.json"`
+	// UIPath is the path of the Swagger UI
+	UIPath string `json:"ui_path" default:"/swagger-ui"`
+	// UIAssets is the base url of the Swagger UI assets, e.g. swagger-ui.css and swagger-ui-bundle.js, the assets
+	// of swagger-ui-dist can be self-hosted by the static assets, e.g. server.static with path /swagger-ui-dist and
+	// dir of the assets, then ui_assets: /swagger-ui-dist
+	UIAssets string `json:"ui_assets" default:"https://cdn.jsdelivr.net/npm/swagger-ui-dist@4.15.5"`
+	// Title is the title of the api
+	Title string `json:"title" default:"${app.name}"`
+	// Description is the description of the api
+	Description string `json:"description"`
+	// Version is the version of the api
+	Version string `json:"version" default:"1.0.0"`
+}
+
 type Env struct {
 	Name  string
 	Value string
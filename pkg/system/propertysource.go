@@ -0,0 +1,222 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/log"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PropertySource is the source of properties other than local config files, e.g. a remote config server,
+// the keys of properties are either flat (server.port) or nested maps
+type PropertySource interface {
+	Name() string
+	Properties() (map[string]interface{}, error)
+}
+
+type httpPropertySource struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPropertySource create the property source that reads properties in json object from url
+func NewHTTPPropertySource(url string, client *http.Client) PropertySource {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &httpPropertySource{url: url, client: client}
+}
+
+// Name returns the url of property source
+func (s *httpPropertySource) Name() string {
+	return s.url
+}
+
+// Properties get properties from url
+func (s *httpPropertySource) Properties() (props map[string]interface{}, err error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v from %v", resp.StatusCode, s.url)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&props)
+	return
+}
+
+type dirPropertySource struct {
+	path string
+}
+
+// NewDirPropertySource create the property source that each file name in path is the key and its content
+// is the value, which is compatible with the Kubernetes ConfigMap mounted as volume
+func NewDirPropertySource(path string) PropertySource {
+	return &dirPropertySource{path: path}
+}
+
+// Name returns the directory of property source
+func (s *dirPropertySource) Name() string {
+	return s.path
+}
+
+// Properties read properties from the files in directory, the hidden files and
+// the ..data links created by Kubernetes are skipped
+func (s *dirPropertySource) Properties() (props map[string]interface{}, err error) {
+	files, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return
+	}
+	props = make(map[string]interface{})
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(s.path, f.Name())
+		// follow the symbolic links
+		info, e := os.Stat(file)
+		if e != nil || info.IsDir() {
+			continue
+		}
+		var content []byte
+		content, err = ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		props[f.Name()] = strings.TrimSpace(string(content))
+	}
+	return
+}
+
+// UnmarshalProperties unmarshal the properties into conf, only the fields of given properties are overridden
+func UnmarshalProperties(props map[string]interface{}, conf interface{}) error {
+	v := viper.New()
+	for key, value := range props {
+		v.Set(key, value)
+	}
+	return v.Unmarshal(conf)
+}
+
+// CachedPropertySource is the property source whose properties are read once and cached until it is refreshed,
+// so that the source is not read again by every configuration that is built from it
+type CachedPropertySource struct {
+	source PropertySource
+	mu     sync.RWMutex
+	props  map[string]interface{}
+	loaded bool
+}
+
+// NewCachedPropertySource create the property source that caches the properties of source
+func NewCachedPropertySource(source PropertySource) *CachedPropertySource {
+	return &CachedPropertySource{source: source}
+}
+
+// Name returns the name of the underlying property source
+func (s *CachedPropertySource) Name() string {
+	return s.source.Name()
+}
+
+// Properties returns the cached properties, the source is read at the first time only,
+// and the error of reading it is not returned again
+func (s *CachedPropertySource) Properties() (props map[string]interface{}, err error) {
+	s.mu.RLock()
+	props, loaded := s.props, s.loaded
+	s.mu.RUnlock()
+	if loaded {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		s.props, err = s.source.Properties()
+		s.loaded = true
+	}
+	return s.props, err
+}
+
+// Refresh read the underlying property source again and update the cache if it succeeds
+func (s *CachedPropertySource) Refresh() (props map[string]interface{}, err error) {
+	props, err = s.source.Properties()
+	if err == nil {
+		s.mu.Lock()
+		s.props, s.loaded = props, true
+		s.mu.Unlock()
+	}
+	return
+}
+
+// refresh read the latest properties of source, the cached property source is refreshed
+func refresh(source PropertySource) (map[string]interface{}, error) {
+	if cached, ok := source.(*CachedPropertySource); ok {
+		return cached.Refresh()
+	}
+	return source.Properties()
+}
+
+// WatchPropertySources polls the property sources every interval in background, onChange is called
+// with the source whose properties are changed, it stops when stop is closed. The properties that are read
+// at first are compared with the later ones, the errors of reading them are returned, and the sources that
+// are failed to read are still watched
+func WatchPropertySources(interval time.Duration, stop <-chan struct{}, onChange func(source PropertySource, props map[string]interface{}), sources ...PropertySource) (err error) {
+	last := make([]map[string]interface{}, len(sources))
+	var errs []string
+	for i, source := range sources {
+		var e error
+		last[i], e = source.Properties()
+		if e != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", source.Name(), e))
+		}
+	}
+	if len(errs) != 0 {
+		err = fmt.Errorf("failed to read property sources %v", strings.Join(errs, ", "))
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for i, source := range sources {
+					props, err := refresh(source)
+					if err != nil {
+						log.Warnf("[system] failed to refresh property source %v: %v", source.Name(), err)
+						continue
+					}
+					if !reflect.DeepEqual(props, last[i]) {
+						last[i] = props
+						onChange(source, props)
+					}
+				}
+			}
+		}
+	}()
+	return
+}
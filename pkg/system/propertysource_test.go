@@ -0,0 +1,139 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/utils/io"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPropertySources(t *testing.T) {
+	var version int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"app.project": "remote-project", "server.port": "%d"}`, 8080+atomic.LoadInt32(&version))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "configmap")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	dataDir := filepath.Join(dir, "..2018_10_01")
+	os.Mkdir(dataDir, 0755)
+	ioutil.WriteFile(filepath.Join(dataDir, "app.name"), []byte("configmap-app\n"), 0644)
+	os.Symlink(dataDir, filepath.Join(dir, "..data"))
+	os.Symlink(filepath.Join(dataDir, "app.name"), filepath.Join(dir, "app.name"))
+	ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("hidden"), 0644)
+
+	t.Run("should read properties from http", func(t *testing.T) {
+		props, err := NewHTTPPropertySource(server.URL+"/config", nil).Properties()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "remote-project", props["app.project"])
+	})
+
+	t.Run("should report error on unexpected status", func(t *testing.T) {
+		_, err := NewHTTPPropertySource(server.URL+"/not-found", nil).Properties()
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should read properties from directory", func(t *testing.T) {
+		props, err := NewDirPropertySource(dir).Properties()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, map[string]interface{}{"app.name": "configmap-app"}, props)
+	})
+
+	t.Run("should report error on missing directory", func(t *testing.T) {
+		_, err := NewDirPropertySource(filepath.Join(dir, "not-exist")).Properties()
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should override local config by property sources", func(t *testing.T) {
+		b := &Builder{
+			Path:       filepath.Join(io.GetWorkDir(), "config"),
+			Name:       "application",
+			FileType:   "yaml",
+			ConfigType: Configuration{},
+			Sources: []PropertySource{
+				NewHTTPPropertySource(server.URL+"/config", nil),
+				NewDirPropertySource(dir),
+			},
+		}
+		cp, err := b.Build()
+		assert.Equal(t, nil, err)
+		c := cp.(*Configuration)
+		assert.Equal(t, "configmap-app", c.App.Name)
+		assert.Equal(t, "remote-project", c.App.Project)
+		assert.Equal(t, "8080", c.Server.Port)
+	})
+
+	t.Run("should read the cached property source only once", func(t *testing.T) {
+		source := NewCachedPropertySource(NewHTTPPropertySource(server.URL+"/config", nil))
+		props, err := source.Properties()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "8080", props["server.port"])
+
+		atomic.StoreInt32(&version, 2)
+		defer atomic.StoreInt32(&version, 0)
+		props, err = source.Properties()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "8080", props["server.port"])
+
+		props, err = source.Refresh()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "8082", props["server.port"])
+		props, _ = source.Properties()
+		assert.Equal(t, "8082", props["server.port"])
+	})
+
+	t.Run("should report the error of reading property sources at first", func(t *testing.T) {
+		stop := make(chan struct{})
+		defer close(stop)
+		err := WatchPropertySources(time.Hour, stop, func(source PropertySource, props map[string]interface{}) {},
+			NewHTTPPropertySource(server.URL+"/not-found", nil))
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("should notify the changed property source", func(t *testing.T) {
+		stop := make(chan struct{})
+		defer close(stop)
+		changed := make(chan map[string]interface{}, 1)
+		WatchPropertySources(10*time.Millisecond, stop, func(source PropertySource, props map[string]interface{}) {
+			changed <- props
+		}, NewHTTPPropertySource(server.URL+"/config", nil), NewDirPropertySource(dir))
+
+		atomic.StoreInt32(&version, 1)
+		select {
+		case props := <-changed:
+			conf := &Configuration{}
+			err := UnmarshalProperties(props, conf)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, "8081", conf.Server.Port)
+		case <-time.After(time.Second):
+			t.Error("property source change is not notified")
+		}
+	})
+}
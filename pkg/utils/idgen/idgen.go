@@ -1,12 +1,16 @@
 package idgen
 
 import (
+	"errors"
 	"fmt"
 	"github.com/sony/sonyflake"
 )
 
 var sf *sonyflake.Sonyflake
 
+// errUnavailable is returned if sonyflake is not created, e.g. the private ip address is not found
+var errUnavailable = errors.New("id generator is unavailable")
+
 func init() {
 	var st sonyflake.Settings
 	sf = sonyflake.NewSonyflake(st)
@@ -17,6 +21,9 @@ func init() {
 
 // Next generates next id as an uint64
 func Next() (id uint64, err error) {
+	if sf == nil {
+		return 0, errUnavailable
+	}
 	var i uint64
 	i, err = sf.NextID()
 	if err == nil {
@@ -27,6 +34,9 @@ func Next() (id uint64, err error) {
 
 // NextString generates next id as a string
 func NextString() (id string, err error) {
+	if sf == nil {
+		return "", errUnavailable
+	}
 	var i uint64
 	i, err = sf.NextID()
 	if err == nil {
@@ -32,7 +32,8 @@ func GetWorkDir() string {
 	return wd
 }
 
-func EnsureWorkDir(skip int, existFile string) bool {
+// EnsureWorkDir change working directory to the nearest directory that contains any of the exist files
+func EnsureWorkDir(skip int, existFiles ...string) bool {
 	var path string
 	if _, file, _, ok := runtime.Caller(skip); ok && strings.Contains(os.Args[0], "go_build_") {
 		path = BaseDir(file)
@@ -42,10 +43,11 @@ func EnsureWorkDir(skip int, existFile string) bool {
 	lastPath := ""
 	for {
 		//log.Debugf("%v", path)
-		configPath := filepath.Join(path, existFile)
-		if !IsPathNotExist(configPath) {
-			ChangeWorkDir(path)
-			return true
+		for _, existFile := range existFiles {
+			if !IsPathNotExist(filepath.Join(path, existFile)) {
+				ChangeWorkDir(path)
+				return true
+			}
 		}
 
 		path = BaseDir(path)
@@ -154,6 +154,9 @@ func TestEnsureWorkDir(t *testing.T) {
 	res = EnsureWorkDir(1, "config/application.yml")
 	assert.Equal(t, true, res)
 	assert.NotEqual(t, wd, GetWorkDir())
+
+	res = EnsureWorkDir(1, "config/application.json", "config/application.yml")
+	assert.Equal(t, true, res)
 }
 
 func TestCallerInfo(t *testing.T) {
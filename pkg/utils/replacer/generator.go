@@ -0,0 +1,122 @@
+// Copyright 2018 John Deng (hi.devops.io@gmail.com).
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replacer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/hidevopsio/hiboot/pkg/utils/idgen"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Generator generates the value of placeholder, args are given by ${name(arg1,arg2)}
+type Generator func(args ...string) (string, error)
+
+var (
+	generators = map[string]Generator{
+		"random.uuid":  randomUUID,
+		"random.value": randomValue,
+		"random.int":   randomInt,
+		"idgen.next":   nextID,
+		"now":          now,
+	}
+	// generatorsMu guards generators, which are read on the refreshes of property sources in the background
+	generatorsMu sync.RWMutex
+)
+
+// AddGenerator add the generator of placeholder ${name}, the built-in generator with the same name is replaced
+func AddGenerator(name string, generator Generator) {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	generators[name] = generator
+}
+
+// generatorOf returns the generator of placeholder ${name}
+func generatorOf(name string) (generator Generator, ok bool) {
+	generatorsMu.RLock()
+	defer generatorsMu.RUnlock()
+	generator, ok = generators[name]
+	return
+}
+
+// randomUUID generates random (version 4) UUID
+func randomUUID(args ...string) (string, error) {
+	u := make([]byte, 16)
+	if _, err := rand.Read(u); err != nil {
+		return "", err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
+
+// randomValue generates random hex string of 32 characters
+func randomValue(args ...string) (string, error) {
+	v := make([]byte, 16)
+	if _, err := rand.Read(v); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(v), nil
+}
+
+// randomInt generates random int in [min, max), ${random.int(max)} is in [0, max),
+// and ${random.int} is a non-negative int32
+func randomInt(args ...string) (string, error) {
+	var bounds []int64
+	for _, arg := range args {
+		n, err := strconv.ParseInt(strings.TrimSpace(arg), 10, 64)
+		if err != nil {
+			return "", err
+		}
+		bounds = append(bounds, n)
+	}
+	min, max := int64(0), int64(1<<31-1)
+	switch len(bounds) {
+	case 0:
+	case 1:
+		max = bounds[0]
+	case 2:
+		min, max = bounds[0], bounds[1]
+	default:
+		return "", fmt.Errorf("too many arguments %v", args)
+	}
+	if max <= min {
+		return "", fmt.Errorf("invalid range [%v, %v)", min, max)
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(max-min))
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(n.Int64()+min, 10), nil
+}
+
+// nextID generates the unique id by idgen
+func nextID(args ...string) (string, error) {
+	return idgen.NextString()
+}
+
+// now returns current time in RFC3339 format, or in the layout given by ${now(2006-01-02)}
+func now(args ...string) (string, error) {
+	layout := time.RFC3339
+	if len(args) != 0 {
+		layout = strings.Join(args, ",")
+	}
+	return time.Now().Format(layout), nil
+}
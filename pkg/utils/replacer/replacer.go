@@ -27,6 +27,10 @@ import (
 
 const (
 	EmptyString = ""
+
+	placeholderPrefix = "${"
+	placeholderSuffix = "}"
+	escapedPrefix     = "\\"
 )
 
 var (
@@ -48,48 +52,137 @@ func GetMatches(source string) [][]string {
 	return ParseVariables(source, compiledRegExp)
 }
 
-// ReplaceStringVariables replace reference and env variables
-func ReplaceStringVariables(source string, t interface{}) interface{} {
-	matches := ParseVariables(source, compiledRegExp)
-
-	for _, match := range matches {
-		varFullName := match[0]
-		// replace references
-		varName := match[1]
-
-		// check if it contains default value
-		var defaultValue string
-		n := strings.Index(varName, ":")
-		if n > 0 {
-			defaultValue = varName[n+1:]
-			varName = varName[:n]
-			//log.Debugf("name: %v, default value: %v", varName, defaultValue)
+// ReplaceStringVariables replace reference, env variables and built-in generators, e.g. ${random.uuid},
+// the references are looked up in roots in order, the placeholders can be nested like ${app.name:${random.value}},
+// and \${name} is escaped to ${name} literally
+func ReplaceStringVariables(source string, roots ...interface{}) interface{} {
+	r := &resolver{roots: roots}
+	retVal := r.expand(source)
+	if r.slice != nil {
+		return r.slice
+	}
+	return retVal
+}
+
+type resolver struct {
+	roots []interface{}
+	slice interface{}
+}
+
+// expand replaces the placeholders in src, the inner placeholders are replaced first
+func (r *resolver) expand(src string) string {
+	var b strings.Builder
+	for i := 0; i < len(src); {
+		switch {
+		case strings.HasPrefix(src[i:], escapedPrefix+placeholderPrefix):
+			end := closingBrace(src, i+len(escapedPrefix))
+			if end < 0 {
+				b.WriteString(src[i:])
+				return b.String()
+			}
+			b.WriteString(src[i+len(escapedPrefix) : end+1])
+			i = end + 1
+		case strings.HasPrefix(src[i:], placeholderPrefix):
+			end := closingBrace(src, i)
+			if end < 0 {
+				b.WriteString(src[i:])
+				return b.String()
+			}
+			expr := r.expand(src[i+len(placeholderPrefix) : end])
+			b.WriteString(r.resolve(expr))
+			i = end + 1
+		default:
+			b.WriteByte(src[i])
+			i++
 		}
+	}
+	return b.String()
+}
 
-		vars := strings.SplitN(varName, ".", -1)
-		refValue := ParseReferences(t, vars)
-		rvType := reflect.TypeOf(refValue)
-		var newValue string
-		switch rvType.Kind() {
-		case reflect.String:
-			newValue = refValue.(string)
-			// replace env
-			if newValue != "" {
-				source = strings.Replace(source, varFullName, newValue, -1)
+// resolve returns the value of expression name(args):default, the placeholder is kept if it can not be resolved
+func (r *resolver) resolve(expr string) string {
+	name, args, defaultValue, hasArgs := parseExpression(expr)
+
+	if g, ok := generatorOf(name); ok {
+		var argList []string
+		if hasArgs {
+			argList = strings.Split(args, ",")
+		}
+		value, err := g(argList...)
+		if err == nil {
+			return value
+		}
+		log.Warnf("failed to generate value of %v: %v", expr, err)
+	} else if !hasArgs {
+		vars := strings.SplitN(name, ".", -1)
+		for _, root := range r.roots {
+			if root == nil {
+				continue
+			}
+			refValue := ParseReferences(root, vars)
+			switch refValue.(type) {
+			case string:
+				if refValue != EmptyString {
+					return refValue.(string)
+				}
+			default:
+				if r.slice == nil {
+					r.slice = refValue
+				}
+				return EmptyString
 			}
-		case reflect.Slice:
-			return refValue
 		}
-		envValue := os.Getenv(varName)
-		if envValue != "" {
-			source = strings.Replace(source, varFullName, envValue, -1)
+		if envValue := os.Getenv(name); envValue != "" {
+			return envValue
 		}
+	}
 
-		if envValue == "" && newValue == "" && defaultValue != "" {
-			source = strings.Replace(source, varFullName, defaultValue, -1)
+	if defaultValue != "" {
+		return defaultValue
+	}
+	return placeholderPrefix + expr + placeholderSuffix
+}
+
+// parseExpression split expression name(args):default, the colons inside the parentheses are part of args
+func parseExpression(expr string) (name, args, defaultValue string, hasArgs bool) {
+	name = expr
+	n := strings.IndexAny(expr, "(:")
+	if n <= 0 {
+		return
+	}
+	rest := expr[n:]
+	name = expr[:n]
+	if rest[0] == '(' {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			name = expr
+			return
+		}
+		args, hasArgs = rest[1:end], true
+		rest = rest[end+1:]
+	}
+	if strings.HasPrefix(rest, ":") {
+		defaultValue = rest[1:]
+	}
+	return
+}
+
+// closingBrace returns the index of the brace that closes the placeholder starts at start, or -1 if it is not closed
+func closingBrace(src string, start int) int {
+	depth := 0
+	for i := start; i < len(src); i++ {
+		switch {
+		case strings.HasPrefix(src[i:], placeholderPrefix):
+			depth++
+			i++
+		case src[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
 		}
 	}
-	return source
+	return -1
 }
 
 // GetFieldValue get filed value in reflected format
@@ -146,26 +239,26 @@ func ParseReferences(st interface{}, varName []string) interface{} {
 }
 
 // ReplaceMap replace references and env variables
-func ReplaceMap(m map[string]interface{}, root interface{}) error {
-	if root == nil {
+func ReplaceMap(m map[string]interface{}, roots ...interface{}) error {
+	if len(roots) == 0 || roots[0] == nil {
 		return NilPointerError
 	}
 	for k, v := range m {
 		// log.Println(k, ": ", v)
 		vt := reflect.TypeOf(v)
 		if vt.Kind() == reflect.String {
-			newStr := ReplaceStringVariables(v.(string), root)
+			newStr := ReplaceStringVariables(v.(string), roots...)
 			m[k] = newStr
 		} else if vt.Kind() == reflect.Map {
 			mv := v.(map[string]interface{})
-			ReplaceMap(mv, root)
+			ReplaceMap(mv, roots...)
 		}
 	}
 	return nil
 }
 
-// Replace given env and reference variables inside specific struct
-func Replace(to interface{}, root interface{}) error {
+// Replace given env and reference variables inside specific struct, the references are looked up in roots in order
+func Replace(to interface{}, roots ...interface{}) error {
 
 	return reflector.ValidateReflectType(to, func(value *reflect.Value, reflectType reflect.Type, fieldSize int, isSlice bool) error {
 		for i := 0; i < fieldSize; i++ {
@@ -182,10 +275,10 @@ func Replace(to interface{}, root interface{}) error {
 
 				if dst.Kind() != reflect.String {
 					child := dst.Addr().Interface()
-					Replace(child, root)
+					Replace(child, roots...)
 				} else {
 					if dv != "" && dstType == "string" && dst.IsValid() && dst.CanSet() {
-						newStr := ReplaceStringVariables(dv, root)
+						newStr := ReplaceStringVariables(dv, roots...)
 						dst.SetString(newStr.(string))
 					}
 				}
@@ -204,20 +297,20 @@ func Replace(to interface{}, root interface{}) error {
 					switch kind {
 					case reflect.String:
 						fv := fmt.Sprintf("%v", fieldValue)
-						newStr := ReplaceStringVariables(fv, root)
+						newStr := ReplaceStringVariables(fv, roots...)
 						dstField.SetString(newStr.(string))
 					//case reflect.Slice:
 					//	log.Debug("slice")
 					case reflect.Map:
 						childMap := dstField.Interface()
 						if !dstField.IsNil() {
-							ReplaceMap(childMap.(map[string]interface{}), root)
+							ReplaceMap(childMap.(map[string]interface{}), roots...)
 						}
 					default:
 						//log.Debug(fieldName, " is a ", kind)
 						child := dstField.Addr()
 						if child.CanInterface() {
-							Replace(child.Interface(), root)
+							Replace(child.Interface(), roots...)
 						}
 					}
 				}
@@ -15,12 +15,17 @@
 package replacer
 
 import (
+	"fmt"
 	"github.com/hidevopsio/hiboot/pkg/log"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type Bar struct {
@@ -274,3 +279,100 @@ func TestGetMatches(t *testing.T) {
 	assert.Equal(t, "app.name", mcs[0][1])
 	assert.Equal(t, "app.role", mcs[1][1])
 }
+
+func TestReplaceGeneratedValues(t *testing.T) {
+	f := &Foo{Name: "foo", Bar: Bar{Name: "bar"}}
+
+	t.Run("should generate random uuid", func(t *testing.T) {
+		s := ReplaceStringVariables("${random.uuid}", f).(string)
+		assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", s)
+		assert.NotEqual(t, s, ReplaceStringVariables("${random.uuid}", f))
+	})
+
+	t.Run("should generate random value", func(t *testing.T) {
+		s := ReplaceStringVariables("${random.value}", f).(string)
+		assert.Regexp(t, "^[0-9a-f]{32}$", s)
+	})
+
+	t.Run("should generate random int in range", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			s := ReplaceStringVariables("${random.int(1, 3)}", f).(string)
+			assert.Contains(t, []string{"1", "2"}, s)
+		}
+		s := ReplaceStringVariables("${random.int(10)}", f).(string)
+		n, err := strconv.Atoi(s)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, n >= 0 && n < 10)
+	})
+
+	t.Run("should keep the placeholder if failed to generate value", func(t *testing.T) {
+		s := ReplaceStringVariables("${random.int(10,1)}", f)
+		assert.Equal(t, "${random.int(10,1)}", s)
+		s = ReplaceStringVariables("${random.int(a):5}", f)
+		assert.Equal(t, "5", s)
+	})
+
+	t.Run("should format current time", func(t *testing.T) {
+		s := ReplaceStringVariables("${now(2006-01-02 15:04)}", f)
+		assert.Equal(t, time.Now().Format("2006-01-02 15:04"), s)
+		_, err := time.Parse(time.RFC3339, ReplaceStringVariables("${now}", f).(string))
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should add generator", func(t *testing.T) {
+		AddGenerator("test.upper", func(args ...string) (string, error) {
+			return strings.ToUpper(strings.Join(args, "-")), nil
+		})
+		s := ReplaceStringVariables("${test.upper(${name},${bar.name})}", f)
+		assert.Equal(t, "FOO-BAR", s)
+	})
+
+	t.Run("should add generator while replacing", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				AddGenerator(fmt.Sprintf("test.concurrent%v", i), randomValue)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				assert.NotEqual(t, "${random.uuid}", ReplaceStringVariables("${random.uuid}", f))
+			}
+		}()
+		wg.Wait()
+	})
+}
+
+func TestReplaceNestedAndEscapedVariables(t *testing.T) {
+	os.Setenv("BAR", "bar")
+	f := &Foo{Name: "foo", Bar: Bar{Name: "bar"}}
+
+	testCases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"should use nested placeholder as default value", "${unknown:${name}-${bar.name}}", "foo-bar"},
+		{"should use nested placeholder as reference name", "${${BAR}.name}", "bar"},
+		{"should keep unresolved placeholder", "${unknown.name}", "${unknown.name}"},
+		{"should escape placeholder", `\${name} is ${name}`, "${name} is foo"},
+		{"should escape nested placeholder", `${unknown:\${name}}`, "${name}"},
+		{"should keep unclosed placeholder", "${name", "${name"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, ReplaceStringVariables(testCase.source, f))
+		})
+	}
+}
+
+func TestReplaceWithRoots(t *testing.T) {
+	foo := &Foo{Name: "foo", Project: "${bar.name}-${name}"}
+	bar := &Foo{Bar: Bar{Name: "bar"}}
+	err := Replace(foo, bar, foo)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bar-foo", foo.Project)
+}
@@ -16,12 +16,120 @@ package validator
 
 import (
 	"gopkg.in/go-playground/validator.v8"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 var Validate *validator.Validate
 
+// Validation is the custom validation of the tag, e.g. `validate:"prefix=hi"`, it can be registered
+// as the component of application, e.g. app.Component(newPrefixValidation)
+type Validation interface {
+	// Tag returns the tag name of the validation
+	Tag() string
+	// Validate validates the field with the param of the tag
+	Validate(field reflect.Value, param string) bool
+}
+
 func init() {
 	config := &validator.Config{TagName: "validate"}
 
 	Validate = validator.New(config)
 }
+
+// Register registers the custom validation on Validate
+func Register(validation Validation) error {
+	return Validate.RegisterValidation(validation.Tag(), func(v *validator.Validate, topStruct reflect.Value, currentStruct reflect.Value,
+		field reflect.Value, fieldType reflect.Type, fieldKind reflect.Kind, param string) bool {
+		return validation.Validate(field, param)
+	})
+}
+
+// FieldError is the failed rule of the field
+type FieldError struct {
+	// Field is the path of the field that is named by its json, form or query tag, e.g. addresses[0].city
+	Field string
+	Rule  string
+	Param string
+}
+
+// nameTags are the tags that name the fields in the request, in the order of precedence
+var nameTags = []string{"json", "form", "query"}
+
+// FieldErrors returns the field errors sorted by field of err that is returned by Validate.Struct(data), the field is
+// the path of the field without the name of top struct, which is named by its json, form or query tag if it has one,
+// e.g. addresses[0].city
+func FieldErrors(data interface{}, err error) (fieldErrors []FieldError) {
+	ves, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return
+	}
+	typ := reflect.TypeOf(data)
+	for _, fe := range ves {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field: fieldPath(typ, fe.FieldNamespace),
+			Rule:  fe.Tag,
+			Param: fe.Param,
+		})
+	}
+	sort.Slice(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Field < fieldErrors[j].Field
+	})
+	return
+}
+
+// fieldPath returns the path of the field by the namespace of its struct fields, e.g. User.Addresses[0].City,
+// the struct fields are renamed by their tags as long as they are found in typ
+func fieldPath(typ reflect.Type, namespace string) string {
+	names := strings.Split(namespace, ".")[1:]
+	for i, name := range names {
+		for typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ == nil || typ.Kind() != reflect.Struct {
+			break
+		}
+		fieldName, index := name, ""
+		if idx := strings.Index(name, "["); idx >= 0 {
+			fieldName, index = name[:idx], name[idx:]
+		}
+		f, ok := typ.FieldByName(fieldName)
+		if !ok {
+			break
+		}
+		names[i] = tagName(f) + index
+		typ = f.Type
+		for n := strings.Count(index, "["); n > 0; n-- {
+			for typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			switch typ.Kind() {
+			case reflect.Array, reflect.Slice, reflect.Map:
+				typ = typ.Elem()
+			}
+		}
+	}
+	path := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "" {
+			path = append(path, name)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// tagName returns the name of the field in its json, form or query tag, or its field name if it has none, the embedded
+// field without the tag is flattened, so that its name is empty
+func tagName(f reflect.StructField) string {
+	for _, tag := range nameTags {
+		name := strings.Split(f.Tag.Get(tag), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	if f.Anonymous {
+		return ""
+	}
+	return f.Name
+}
@@ -16,7 +16,10 @@ package validator
 
 import (
 	"fmt"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/go-playground/validator.v8"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -88,3 +91,73 @@ func TestValidateField(t *testing.T) {
 
 	// email ok, move on
 }
+
+type prefixValidation struct{}
+
+func (v *prefixValidation) Tag() string {
+	return "prefix"
+}
+
+func (v *prefixValidation) Validate(field reflect.Value, param string) bool {
+	return strings.HasPrefix(field.String(), param)
+}
+
+type Greeting struct {
+	Message string `validate:"prefix=hi"`
+}
+
+func TestRegister(t *testing.T) {
+	err := Register(new(prefixValidation))
+	assert.Equal(t, nil, err)
+
+	t.Run("should pass custom validation", func(t *testing.T) {
+		err := Validate.Struct(&Greeting{Message: "hi, there"})
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("should fail custom validation", func(t *testing.T) {
+		err := Validate.Struct(&Greeting{Message: "hello"})
+		assert.Equal(t, "prefix", err.(validator.ValidationErrors)["Greeting.Message"].Tag)
+	})
+}
+
+func TestFieldErrors(t *testing.T) {
+	t.Run("should return field errors", func(t *testing.T) {
+		err := Validate.Struct(&User{Age: 135, Email: "badger", FavouriteColor: "#000", Addresses: []*Address{{}}})
+		fieldErrors := FieldErrors(&User{}, err)
+		assert.Equal(t, "Addresses[0].City", fieldErrors[0].Field)
+		assert.Equal(t, "required", fieldErrors[0].Rule)
+		assert.Equal(t, "Age", fieldErrors[4].Field)
+		assert.Equal(t, "lte", fieldErrors[4].Rule)
+		assert.Equal(t, "130", fieldErrors[4].Param)
+	})
+
+	t.Run("should return nil if it is not validation error", func(t *testing.T) {
+		assert.Equal(t, 0, len(FieldErrors(&User{}, fmt.Errorf("oops"))))
+	})
+
+	t.Run("should name the fields by json, form or query tags", func(t *testing.T) {
+		req := &Order{Items: []*Item{{}}}
+		fieldErrors := FieldErrors(req, Validate.Struct(req))
+		assert.Equal(t, 4, len(fieldErrors))
+		assert.Equal(t, "ContactPhone", fieldErrors[0].Field)
+		assert.Equal(t, "customer_name", fieldErrors[1].Field)
+		assert.Equal(t, "items[0].sku", fieldErrors[2].Field)
+		assert.Equal(t, "status", fieldErrors[3].Field)
+	})
+}
+
+type Meta struct {
+	Status string `query:"status" validate:"required"`
+}
+
+type Item struct {
+	Sku string `json:"sku,omitempty" validate:"required"`
+}
+
+type Order struct {
+	Meta
+	CustomerName string  `json:"customer_name" validate:"required"`
+	ContactPhone string  `json:"-" validate:"required"`
+	Items        []*Item `form:"items" validate:"required,dive,required"`
+}